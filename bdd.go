@@ -0,0 +1,439 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+)
+
+// BDD is a reduced ordered binary decision diagram of a
+// predicate, where the paths from the root test the variables
+// in the order of Vars, and equal subdiagrams are shared
+type BDD struct {
+	// Vars has the variables of the predicate, in the order
+	// they are tested
+	Vars []string
+	root int
+	// nodes has the decisions, where 0 and 1 are the constants
+	// false and true, and every node comes after its branches
+	nodes  []bddNode
+	unique map[bddNode]int
+	cache  map[[3]int]int
+	levels map[string]int
+	// limit is the maximum amount of decision nodes, zero
+	// means there's no limit
+	limit int
+	e     error
+}
+
+// bddNode tests the variable at level, continuing with lo when
+// it's false and hi when it's true
+type bddNode struct {
+	level, lo, hi int
+}
+
+// LimitErr is returned when a decision diagram has more nodes
+// than allowed
+type LimitErr struct {
+	Nodes int
+}
+
+func (l *LimitErr) Error() (s string) {
+	s = fmt.Sprintf("Decision diagram with more than %d nodes", l.Nodes)
+	return
+}
+
+// Compile returns the BDD of p, with its variables tested in
+// alphabetical order. p must be propositional, as described in
+// UnsupportedErr
+func Compile(p *Predicate) (b *BDD, e error) {
+	b, e = compile(p, 0)
+	return
+}
+
+// compile returns the BDD of p, or a LimitErr when building it
+// creates more than limit decision nodes, unless limit is zero
+func compile(p *Predicate, limit int) (b *BDD, e error) {
+	x, e := propositional(p)
+	if e == nil {
+		b = newBDD(Vars(x), limit)
+		b.root = b.build(x)
+		e = b.e
+	}
+	if e != nil {
+		b = nil
+	}
+	return
+}
+
+func newBDD(vs []string, limit int) (b *BDD) {
+	n := len(vs)
+	b = &BDD{
+		Vars:   vs,
+		nodes:  []bddNode{{n, 0, 0}, {n, 1, 1}},
+		unique: make(map[bddNode]int),
+		cache:  make(map[[3]int]int),
+		levels: make(map[string]int),
+		limit:  limit,
+	}
+	for i, v := range vs {
+		b.levels[v] = i
+	}
+	return
+}
+
+// build returns the node equivalent to p
+func (b *BDD) build(p *Predicate) (n int) {
+	switch p.Operator {
+	case Term:
+		if p.String == TrueStr {
+			n = 1
+		} else if p.String != FalseStr {
+			n = b.mk(b.levels[p.String], 0, 1)
+		}
+	case AtomOp:
+		// an atom is a variable named by its string
+		n = b.mk(b.levels[String(p)], 0, 1)
+	case NotOp:
+		n = b.not(b.build(p.B))
+	case AndOp:
+		n = b.ite(b.build(p.A), b.build(p.B), 0)
+	case OrOp:
+		n = b.ite(b.build(p.A), 1, b.build(p.B))
+	case NandOp:
+		n = b.not(b.ite(b.build(p.A), b.build(p.B), 0))
+	case NorOp:
+		n = b.not(b.ite(b.build(p.A), 1, b.build(p.B)))
+	case ImpliesOp:
+		n = b.ite(b.build(p.A), b.build(p.B), 1)
+	case FollowsOp:
+		n = b.ite(b.build(p.B), b.build(p.A), 1)
+	case EquivalesOp:
+		x := b.build(p.B)
+		n = b.ite(b.build(p.A), x, b.not(x))
+	case NotEquivalesOp, XorOp:
+		x := b.build(p.B)
+		n = b.ite(b.build(p.A), b.not(x), x)
+	case IfOp:
+		n = b.ite(b.build(p.A), b.build(p.B), b.build(p.C))
+	case AtMostOp, AtLeastOp, ExactlyOp:
+		n = b.card(p)
+	}
+	return
+}
+
+// card returns the node of the cardinality constraint p,
+// counting the true operands like a sequential counter
+func (b *BDD) card(p *Predicate) (n int) {
+	m := p.K + 1
+	if m > len(p.Args) {
+		m = len(p.Args)
+	}
+	// s[j] is the node of at least j operands being true, among
+	// the ones already counted
+	s := make([]int, m+1)
+	s[0] = 1
+	for _, a := range p.Args {
+		x := b.build(a)
+		for j := m; j > 0; j-- {
+			s[j] = b.ite(x, s[j-1], s[j])
+		}
+	}
+	atLeast := func(j int) (r int) {
+		if j <= 0 {
+			r = 1
+		} else if j <= m {
+			r = s[j]
+		}
+		return
+	}
+	if p.Operator == AtLeastOp {
+		n = atLeast(p.K)
+	} else if p.Operator == AtMostOp {
+		n = b.not(atLeast(p.K + 1))
+	} else {
+		n = b.ite(atLeast(p.K), b.not(atLeast(p.K+1)), 0)
+	}
+	return
+}
+
+func (b *BDD) not(f int) (n int) {
+	n = b.ite(f, 0, 1)
+	return
+}
+
+// ite returns the node of if f then g else h
+func (b *BDD) ite(f, g, h int) (n int) {
+	key := [3]int{f, g, h}
+	c, cached := b.cache[key]
+	if b.e != nil || f == 1 || g == h {
+		n = g
+	} else if f == 0 {
+		n = h
+	} else if g == 1 && h == 0 {
+		n = f
+	} else if cached {
+		n = c
+	} else {
+		v := b.nodes[f].level
+		if l := b.nodes[g].level; l < v {
+			v = l
+		}
+		if l := b.nodes[h].level; l < v {
+			v = l
+		}
+		f0, f1 := b.cofactors(f, v)
+		g0, g1 := b.cofactors(g, v)
+		h0, h1 := b.cofactors(h, v)
+		n = b.mk(v, b.ite(f0, g0, h0), b.ite(f1, g1, h1))
+		b.cache[key] = n
+	}
+	return
+}
+
+// cofactors returns the branches of f for the variable at
+// level v, which are f itself when it doesn't test it
+func (b *BDD) cofactors(f, v int) (lo, hi int) {
+	lo, hi = f, f
+	if b.nodes[f].level == v {
+		lo, hi = b.nodes[f].lo, b.nodes[f].hi
+	}
+	return
+}
+
+// restrict returns the node of f with the variable name
+// replaced by v, which is f when b doesn't have that variable
+func (b *BDD) restrict(f int, name string, v bool) (n int) {
+	level, ok := b.levels[name]
+	memo := make(map[int]int)
+	var rf func(int) int
+	rf = func(x int) (r int) {
+		nd := b.nodes[x]
+		r, done := memo[x]
+		if !done && (!ok || nd.level > level) {
+			r = x
+		} else if !done && nd.level == level && v {
+			r = nd.hi
+		} else if !done && nd.level == level {
+			r = nd.lo
+		} else if !done {
+			r = b.mk(nd.level, rf(nd.lo), rf(nd.hi))
+			memo[x] = r
+		}
+		return
+	}
+	n = rf(f)
+	return
+}
+
+// mk returns the node testing the variable at level, sharing
+// equal nodes and skipping the ones with equal branches
+func (b *BDD) mk(level, lo, hi int) (n int) {
+	x := bddNode{level, lo, hi}
+	m, ok := b.unique[x]
+	if lo == hi || b.e != nil {
+		n = lo
+	} else if ok {
+		n = m
+	} else if b.limit != 0 && len(b.nodes)-2 == b.limit {
+		b.e = &LimitErr{Nodes: b.limit}
+	} else {
+		n = len(b.nodes)
+		b.nodes = append(b.nodes, x)
+		b.unique[x] = n
+	}
+	return
+}
+
+// quantify returns the node of f with the variables at the
+// levels in qs quantified existentially when exists is true,
+// and universally otherwise
+func (b *BDD) quantify(f int, qs map[int]bool, exists bool) (n int) {
+	memo := make(map[int]int)
+	var qf func(int) int
+	qf = func(x int) (r int) {
+		m, ok := memo[x]
+		if x <= 1 {
+			r = x
+		} else if ok {
+			r = m
+		} else {
+			nd := b.nodes[x]
+			lo, hi := qf(nd.lo), qf(nd.hi)
+			if qs[nd.level] && exists {
+				// ∃v : f ≡ f[v := false] ∨ f[v := true]
+				r = b.ite(lo, 1, hi)
+			} else if qs[nd.level] {
+				// ∀v : f ≡ f[v := false] ∧ f[v := true]
+				r = b.ite(lo, hi, 0)
+			} else {
+				r = b.mk(nd.level, lo, hi)
+			}
+			memo[x] = r
+		}
+		return
+	}
+	n = qf(f)
+	return
+}
+
+// predicate returns a predicate equivalent to the node n, where
+// terms has the term or atom of each variable. The decisions
+// reached by more than one branch are named by let expressions
+// instead of repeated, so the predicate grows like the diagram
+// and not like its unfolding as a tree
+func (b *BDD) predicate(n int, terms map[string]*Predicate) (r *Predicate) {
+	refs := make(map[int]int)
+	var count func(int)
+	count = func(x int) {
+		if x > 1 {
+			refs[x] = refs[x] + 1
+			if refs[x] == 1 {
+				count(b.nodes[x].lo)
+				count(b.nodes[x].hi)
+			}
+		}
+	}
+	count(n)
+	// the shared decisions, which come after their branches,
+	// except the ones of a single literal
+	var shared []int
+	for x := 2; x <= n; x++ {
+		nd := b.nodes[x]
+		if refs[x] > 1 && (nd.lo > 1 || nd.hi > 1) {
+			shared = append(shared, x)
+		}
+	}
+	vars := make(map[string]bool)
+	for _, v := range b.Vars {
+		vars[v] = true
+	}
+	names, k := make(map[int]string), 0
+	for _, x := range shared {
+		for names[x] == "" || vars[names[x]] {
+			k = k + 1
+			names[x] = fmt.Sprintf("n%d", k)
+		}
+	}
+	r = b.decision(n, terms, names)
+	for i := len(shared); i != 0; i-- {
+		x := shared[i-1]
+		r = &Predicate{Operator: LetOp, String: names[x],
+			A: b.decision(x, terms, names), B: r}
+	}
+	return
+}
+
+// decision returns a predicate equivalent to the node n, with
+// its branches that have a name in names replaced by it
+func (b *BDD) decision(n int, terms map[string]*Predicate,
+	names map[int]string) (r *Predicate) {
+	branch := func(x int) (p *Predicate) {
+		if names[x] != "" {
+			p = NewTerm(names[x])
+		} else {
+			p = b.decision(x, terms, names)
+		}
+		return
+	}
+	if n <= 1 {
+		r = constant(n == 1)
+	} else {
+		nd := b.nodes[n]
+		x := terms[b.Vars[nd.level]]
+		lo, hi := nd.lo, nd.hi
+		if lo == 0 && hi == 1 {
+			r = x
+		} else if lo == 1 && hi == 0 {
+			r = negate(x)
+		} else if lo == 0 {
+			r = &Predicate{Operator: AndOp, A: x, B: branch(hi)}
+		} else if hi == 0 {
+			r = &Predicate{Operator: AndOp, A: negate(x), B: branch(lo)}
+		} else if lo == 1 {
+			r = &Predicate{Operator: ImpliesOp, A: x, B: branch(hi)}
+		} else if hi == 1 {
+			r = &Predicate{Operator: OrOp, A: x, B: branch(lo)}
+		} else {
+			r = &Predicate{Operator: IfOp, A: x, B: branch(hi),
+				C: branch(lo)}
+		}
+	}
+	return
+}
+
+// Size returns the amount of decision nodes of b, without the
+// constants
+func (b *BDD) Size() (n int) {
+	seen := make(map[int]bool)
+	var visit func(int)
+	visit = func(x int) {
+		if x > 1 && !seen[x] {
+			seen[x] = true
+			visit(b.nodes[x].lo)
+			visit(b.nodes[x].hi)
+		}
+	}
+	visit(b.root)
+	n = len(seen)
+	return
+}
+
+// value returns the value of b for the assignment m
+func (b *BDD) value(m map[string]bool) (v bool) {
+	n := b.root
+	for n > 1 {
+		x := b.nodes[n]
+		if m[b.Vars[x.level]] {
+			n = x.hi
+		} else {
+			n = x.lo
+		}
+	}
+	v = n == 1
+	return
+}
+
+// Probability returns the probability of b being true when its
+// variables are independent, and each one is true with the
+// probability in ps, or 1/2 when it isn't there
+func (b *BDD) Probability(ps map[string]float64) (r float64) {
+	r = b.probability(b.root, ps)
+	return
+}
+
+// probability returns the probability of the node f being true,
+// like Probability
+func (b *BDD) probability(f int, ps map[string]float64) (r float64) {
+	// the branches of each node come before it
+	pr := make([]float64, len(b.nodes))
+	pr[1] = 1
+	for i := 2; i <= f; i++ {
+		x := b.nodes[i]
+		q, ok := ps[b.Vars[x.level]]
+		if !ok {
+			q = 0.5
+		}
+		pr[i] = q*pr[x.hi] + (1-q)*pr[x.lo]
+	}
+	r = pr[f]
+	return
+}
@@ -0,0 +1,74 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	ps := []struct {
+		pred string
+		size int
+	}{
+		{"A", 1},
+		{"true", 0},
+		{"A ∧ ¬A", 0},
+		{"¬A ∧ (B ∨ C)", 3},
+		{"A ⇒ B ≡ ¬C", 4},
+		{"A ⇐ B ⊕ C", 4},
+		{"(A ↑ B) ∧ (B ↓ C)", 2},
+		{"if A then B else C ∨ D", 4},
+		{"let x := A ∨ B in x ∧ ¬x", 0},
+		{"atmost(1; A, B, C, D)", 6},
+		{"atleast(2; A, B ∧ C, D, E)", 8},
+		{"exactly(1; A, B, C)", 5},
+		{"atleast(3; A, B)", 0},
+		{"∀ x ∈ {a, b} : p(x) ⇒ q(x)", 6},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		b, e := Compile(p)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].size, b.Size(), "At %d", i)
+		forallAssign(b.Vars, func(m map[string]bool) {
+			v := Reduce(p, assignInterp(m)).String == TrueStr
+			require.Equal(t, v, b.value(m), "At %d %v", i, m)
+		})
+	}
+	alg.Forall(inf, len(ps))
+
+	p, e := Parse(strings.NewReader("a < 1"))
+	require.NoError(t, e)
+	_, e = Compile(p)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+	p, e = Parse(strings.NewReader("A ∧ B ∧ C"))
+	require.NoError(t, e)
+	_, e = compile(p, 4)
+	require.Equal(t, &LimitErr{Nodes: 4}, e)
+	b, e := compile(p, 5)
+	require.NoError(t, e)
+	require.Equal(t, 3, b.Size())
+}
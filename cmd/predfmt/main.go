@@ -0,0 +1,213 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+// Predfmt formats .pred files, which contain predicates
+// separated by semicolons and comments. Without flags it writes
+// the formatted files to standard output, reading standard
+// input if no file is given.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	pred "github.com/lamg/predicate"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	var list, write, diff bool
+	flag.BoolVar(&list, "l", false,
+		"list files whose formatting differs from predfmt's")
+	flag.BoolVar(&write, "w", false,
+		"write result to (source) file instead of stdout")
+	flag.BoolVar(&diff, "d", false, "display diffs instead of "+
+		"rewriting files")
+	flag.Parse()
+	exit := 0
+	report := func(e error) {
+		fmt.Fprintln(os.Stderr, e.Error())
+		exit = 2
+	}
+	if flag.NArg() == 0 {
+		if write {
+			report(fmt.Errorf("cannot use -w with standard input"))
+		} else {
+			src, e := ioutil.ReadAll(os.Stdin)
+			if e == nil {
+				e = process(os.Stdout, "<standard input>", src, list, false,
+					diff)
+			}
+			if e != nil {
+				report(e)
+			}
+		}
+	}
+	for _, name := range flag.Args() {
+		src, e := ioutil.ReadFile(name)
+		if e == nil {
+			e = process(os.Stdout, name, src, list, write, diff)
+		}
+		if e != nil {
+			report(e)
+		}
+	}
+	os.Exit(exit)
+}
+
+// process formats src, the content of the file name, writing
+// to out what the flags select
+func process(out io.Writer, name string, src []byte, list, write,
+	diff bool) (e error) {
+	var res []byte
+	res, e = format(name, src)
+	changed := e == nil && !bytes.Equal(src, res)
+	if changed && list {
+		fmt.Fprintln(out, name)
+	}
+	if changed && write {
+		var fi os.FileInfo
+		fi, e = os.Stat(name)
+		if e == nil {
+			e = ioutil.WriteFile(name, res, fi.Mode().Perm())
+		}
+	}
+	if changed && diff {
+		fmt.Fprint(out, lineDiff(name, string(src), string(res)))
+	}
+	if e == nil && !list && !write && !diff {
+		_, e = out.Write(res)
+	}
+	return
+}
+
+// format parses every predicate in src and prints it with the
+// canonical printer, with its inline comments next to their
+// operands, followed by a semicolon and its trailing comments,
+// and preceded by the comments before it. Statements with
+// comments before them are separated from the previous one by a
+// blank line
+func format(name string, src []byte) (res []byte, e error) {
+	ss := pred.ParseAll(bytes.NewReader(src))
+	var sb strings.Builder
+	var errs []string
+	// names are printed as terms, which quotes them when needed
+	ident := func(s string) string {
+		return canonical.String(pred.NewTerm(s))
+	}
+	for i, st := range ss {
+		if st.Error != nil {
+			errs = append(errs,
+				fmt.Sprintf("%s:%d: %s", name, st.Line, st.Error.Error()))
+		}
+		if i != 0 && len(st.Comments) != 0 {
+			sb.WriteString("\n")
+		}
+		for _, c := range st.Comments {
+			sb.WriteString(c.Text + "\n")
+		}
+		if st.Definition != "" {
+			sb.WriteString(pred.DefKw + " " + ident(st.Definition) + " " +
+				pred.AssignOp + " ")
+		}
+		if st.Predicate != nil {
+			pr := *canonical
+			pr.Comments = st.Inline
+			sb.WriteString(pr.String(st.Predicate) + ";")
+		} else if st.Domain != "" {
+			es := make([]string, len(st.Elements))
+			for j, x := range st.Elements {
+				es[j] = ident(x)
+			}
+			sb.WriteString(pred.DomainKw + " " + ident(st.Domain) + " " +
+				pred.AssignOp + " " + pred.OBrace +
+				strings.Join(es, pred.Comma+" ") + pred.CBrace + ";")
+		}
+		for _, c := range st.Trailing {
+			sb.WriteString(" " + c.Text)
+		}
+		if st.Predicate != nil || st.Domain != "" {
+			sb.WriteString("\n")
+		}
+	}
+	if len(errs) != 0 {
+		e = errors.New(strings.Join(errs, "\n"))
+	} else {
+		res = []byte(sb.String())
+	}
+	return
+}
+
+var canonical = &pred.Printer{Width: 80}
+
+// lineDiff returns the differences between a and b as a
+// unified diff with a single hunk covering both files, computed
+// with their longest common subsequence of lines
+func lineDiff(name, a, b string) (r string) {
+	xs, ys := splitLines(a), splitLines(b)
+	// lcs[i][j] is the length of the longest common
+	// subsequence of xs[i:] and ys[j:]
+	lcs := make([][]int, len(xs)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(ys)+1)
+	}
+	for i := len(xs) - 1; i >= 0; i-- {
+		for j := len(ys) - 1; j >= 0; j-- {
+			if xs[i] == ys[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s.orig\n+++ %s\n@@ -1,%d +1,%d @@\n", name,
+		name, len(xs), len(ys))
+	i, j := 0, 0
+	for i != len(xs) || j != len(ys) {
+		if i != len(xs) && j != len(ys) && xs[i] == ys[j] {
+			sb.WriteString(" " + xs[i] + "\n")
+			i, j = i+1, j+1
+		} else if j == len(ys) ||
+			(i != len(xs) && lcs[i+1][j] >= lcs[i][j+1]) {
+			sb.WriteString("-" + xs[i] + "\n")
+			i = i + 1
+		} else {
+			sb.WriteString("+" + ys[j] + "\n")
+			j = j + 1
+		}
+	}
+	r = sb.String()
+	return
+}
+
+func splitLines(s string) (ls []string) {
+	ls = strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if s == "" {
+		ls = nil
+	}
+	return
+}
@@ -0,0 +1,115 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	ps := []struct {
+		src, out string
+	}{
+		{"A∧B;C ∨¬ D", "A ∧ B;\nC ∨ ¬D;\n"},
+		{"-- header\nA ∧ B; -- why A\n-- next\nC;\n",
+			"-- header\nA ∧ B; -- why A\n\n-- next\nC;\n"},
+		{"A ∧ -- first\nB ∧ C;",
+			"   A\n∧  -- first\n   B\n∧  C;\n"},
+		{"A (* a *) ∨ ¬(* b *) B;", "A (* a *) ∨ ¬(* b *) B;\n"},
+		{"(A ∨ B) -- group\n∧ C;", "   (A ∨ B) -- group\n∧  C;\n"},
+		{"A ∧ B -- end\n;", "A ∧ B; -- end\n"},
+		{"def x := A ∨ B; domain D := {a, b}; -- elements\n",
+			"def x := A ∨ B;\ndomain D := {a, b}; -- elements\n"},
+		{"A;\n(* the end *)", "A;\n\n(* the end *)\n"},
+	}
+	inf := func(i int) {
+		res, e := format("f.pred", []byte(ps[i].src))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].out, string(res), "At %d", i)
+		// formatting is idempotent
+		again, e := format("f.pred", res)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].out, string(again), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	_, e := format("f.pred", []byte("A;\nB ∧;\nC ∨ ∨ D;"))
+	require.EqualError(t, e, "f.pred:2: Not recognized ';', "+
+		"expecting one of [identifier (]\n"+
+		"f.pred:3: Not recognized '∨', expecting one of [identifier (]")
+}
+
+func TestLineDiff(t *testing.T) {
+	ps := []struct {
+		a, b, diff string
+	}{
+		{"A;\nB;\n", "A;\nC;\n",
+			"--- f.orig\n+++ f\n@@ -1,2 +1,2 @@\n A;\n-B;\n+C;\n"},
+		{"", "A;\n", "--- f.orig\n+++ f\n@@ -1,0 +1,1 @@\n+A;\n"},
+		{"A;\nB;\nC;\n", "B;\n",
+			"--- f.orig\n+++ f\n@@ -1,3 +1,1 @@\n-A;\n B;\n-C;\n"},
+	}
+	inf := func(i int) {
+		require.Equal(t, ps[i].diff, lineDiff("f", ps[i].a, ps[i].b),
+			"At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestProcess(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f.pred")
+	src := []byte("A∧B; -- why\n")
+	ps := []struct {
+		list, write, diff bool
+		out               string
+		file              string
+	}{
+		{false, false, false, "A ∧ B; -- why\n", "A∧B; -- why\n"},
+		{true, false, false, name + "\n", "A∧B; -- why\n"},
+		{false, false, true, "--- " + name + ".orig\n+++ " + name +
+			"\n@@ -1,1 +1,1 @@\n-A∧B; -- why\n+A ∧ B; -- why\n",
+			"A∧B; -- why\n"},
+		{false, true, false, "", "A ∧ B; -- why\n"},
+		{true, false, false, "", "A ∧ B; -- why\n"},
+	}
+	require.NoError(t, ioutil.WriteFile(name, src, 0644))
+	inf := func(i int) {
+		cur, e := ioutil.ReadFile(name)
+		require.NoError(t, e, "At %d", i)
+		var out bytes.Buffer
+		e = process(&out, name, cur, ps[i].list, ps[i].write, ps[i].diff)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].out, out.String(), "At %d", i)
+		cur, e = ioutil.ReadFile(name)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].file, string(cur), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	var out bytes.Buffer
+	e := process(&out, name, []byte("A ∧;"), false, true, false)
+	require.Error(t, e)
+	require.Empty(t, out.String())
+}
@@ -21,33 +21,240 @@
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	pred "github.com/lamg/predicate"
+	"io"
 	"log"
 	"os"
-	"strings"
 )
 
 func main() {
-	sc := bufio.NewScanner(os.Stdin)
-	for sc.Scan() {
-		t := sc.Text()
-		p, e := pred.Parse(strings.NewReader(t))
-		if e == nil {
-			stdInterp := func(name string) (val, def bool) {
-				val, def = name == pred.TrueStr,
-					name == pred.TrueStr || name == pred.FalseStr
-				return
+	if len(os.Args) > 1 && os.Args[1] == "taut" {
+		taut(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diff(os.Args[2:])
+	} else {
+		reduce()
+	}
+}
+
+// reduce writes the reduced predicates of the standard input
+func reduce() {
+	var dot, dimacs, core bool
+	var format, card string
+	flag.BoolVar(&dot, "dot", false,
+		"write the reduced predicates as Graphviz DOT digraphs")
+	flag.BoolVar(&dimacs, "dimacs", false,
+		"write the reduced predicates as DIMACS CNF")
+	flag.BoolVar(&core, "core", false,
+		"write a minimal subset of the predicates that contradict each other")
+	flag.StringVar(&card, "card", "sequential",
+		"CNF encoding of cardinality constraints: sequential or totalizer")
+	flag.StringVar(&format, "format", "text",
+		"output format: text, latex, mathml or html")
+	flag.Parse()
+	formats := map[string]func(*pred.Predicate) string{
+		"text":   pred.String,
+		"latex":  pred.LaTeX,
+		"mathml": pred.MathML,
+		"html":   pred.HTML,
+	}
+	str, ok := formats[format]
+	if !ok {
+		log.Fatalf("Unknown format '%s'", format)
+	}
+	encodings := map[string]pred.CardEncoding{
+		"sequential": pred.SequentialCounter,
+		"totalizer":  pred.Totalizer,
+	}
+	enc, ok := encodings[card]
+	if !ok {
+		log.Fatalf("Unknown cardinality encoding '%s'", card)
+	}
+	stdInterp := func(name string) (val, def bool) {
+		val, def = name == pred.TrueStr,
+			name == pred.TrueStr || name == pred.FalseStr
+		return
+	}
+	ss := pred.ParseAll(os.Stdin)
+	defs, e := pred.Definitions(ss)
+	if e != nil {
+		log.Fatal(e)
+	}
+	doms, e := pred.DomainsOf(ss)
+	if e != nil {
+		log.Fatal(e)
+	}
+	// rules has the predicates checked by -core, and their
+	// statements
+	var rules []*pred.Predicate
+	var stms []*pred.Statement
+	// failed is true when a statement couldn't be reduced, which
+	// makes the exit status 1
+	failed := false
+	for _, st := range ss {
+		var x *pred.Predicate
+		e = nil
+		if st.Predicate != nil && st.Definition == "" {
+			x, e = pred.Expand(st.Predicate, defs)
+		}
+		if x != nil && e == nil {
+			x, e = pred.Ground(x, doms)
+		}
+		if x != nil && core {
+			rules, stms = append(rules, x), append(stms, st)
+		} else if x != nil {
+			np := pred.Reduce(x, stdInterp)
+			if dot {
+				e = pred.WriteDot(os.Stdout, np, true)
+			} else if dimacs {
+				var c *pred.CNF
+				c, e = pred.ToCNF(np, enc)
+				if e == nil {
+					e = pred.WriteDIMACS(os.Stdout, c)
+				}
+			} else {
+				fmt.Println(str(np))
 			}
-			np := pred.Reduce(p, stdInterp)
-			fmt.Println(pred.String(np))
-		} else {
+		}
+		if st.Error != nil {
+			e = fmt.Errorf("line %d: %s", st.Line, st.Error.Error())
+		}
+		if e != nil {
 			log.Println(e.Error())
+			failed = true
+		}
+	}
+	if core {
+		cs, e := pred.UnsatCore(rules)
+		if e != nil {
+			log.Fatal(e)
+		}
+		if len(cs) == 0 {
+			fmt.Println("satisfiable")
+		}
+		for _, i := range cs {
+			fmt.Printf("line %d: %s\n", stms[i].Line,
+				pred.String(stms[i].Predicate))
 		}
 	}
-	e := sc.Err()
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// taut proves that the conjunction of the predicates in the
+// standard input is a tautology, writing the proof when asked
+func taut(args []string) {
+	fs := flag.NewFlagSet("taut", flag.ExitOnError)
+	var proof, cnf string
+	fs.StringVar(&proof, "proof", "",
+		"write the DRAT proof of the tautology to this file")
+	fs.StringVar(&cnf, "cnf", "",
+		"write the DIMACS CNF refuted by the proof to this file")
+	fs.Parse(args)
+	p := conjunction(os.Stdin, "the standard input")
+	r, e := pred.Prove(p)
+	var c *pred.CounterexampleErr
+	if errors.As(e, &c) {
+		fmt.Println(c.Error())
+		os.Exit(1)
+	}
+	if e == nil {
+		e = r.Check()
+	}
+	if e == nil && proof != "" {
+		e = writeFile(proof, func(f *os.File) error {
+			return pred.WriteDRAT(f, r)
+		})
+	}
+	if e == nil && cnf != "" {
+		e = writeFile(cnf, func(f *os.File) error {
+			return pred.WriteDIMACS(f, r.CNF)
+		})
+	}
 	if e != nil {
 		log.Fatal(e)
 	}
+	fmt.Println("tautology")
+}
+
+// diff writes the structural and semantic differences between
+// the conjunctions of the predicates in two files
+func diff(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: reduce diff old.pred new.pred")
+	}
+	ps := make([]*pred.Predicate, 2)
+	for i, name := range args {
+		f, e := os.Open(name)
+		if e != nil {
+			log.Fatal(e)
+		}
+		ps[i] = conjunction(f, name)
+		f.Close()
+	}
+	d, e := pred.Diff(ps[0], ps[1])
+	fmt.Print(d.String())
+	var u *pred.UnsupportedErr
+	if errors.As(e, &u) {
+		fmt.Printf("no examples: %s\n", e.Error())
+	} else if e != nil {
+		log.Fatal(e)
+	} else if len(d.Accepted) == 0 && len(d.Rejected) == 0 {
+		fmt.Println("equivalent")
+	}
+}
+
+// conjunction returns the conjunction of the predicates read
+// from rd, with the definitions expanded and the quantifiers
+// grounded, exiting when there's an error in the input called
+// name
+func conjunction(rd io.Reader, name string) (p *pred.Predicate) {
+	ss := pred.ParseAll(rd)
+	defs, e := pred.Definitions(ss)
+	if e == nil {
+		var doms pred.Domains
+		doms, e = pred.DomainsOf(ss)
+		for i := 0; e == nil && i != len(ss); i++ {
+			st := ss[i]
+			var x *pred.Predicate
+			if st.Error != nil {
+				e = st.Error
+			} else if st.Predicate != nil && st.Definition == "" {
+				x, e = pred.Expand(st.Predicate, defs)
+			}
+			if x != nil && e == nil {
+				x, e = pred.Ground(x, doms)
+			}
+			if e != nil {
+				e = fmt.Errorf("line %d: %s", st.Line, e.Error())
+			} else if x != nil && p == nil {
+				p = x
+			} else if x != nil {
+				p = &pred.Predicate{Operator: pred.AndOp, A: p, B: x}
+			}
+		}
+	}
+	if e == nil && p == nil {
+		e = errors.New("no predicates")
+	}
+	if e != nil {
+		log.Fatalf("%s: %s", name, e.Error())
+	}
+	return
+}
+
+// writeFile creates the file name and writes it with w
+func writeFile(name string, w func(*os.File) error) (e error) {
+	f, e := os.Create(name)
+	if e == nil {
+		e = w(f)
+		if ce := f.Close(); e == nil {
+			e = ce
+		}
+	}
+	return
 }
@@ -0,0 +1,378 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CNF is a conjunction of clauses, which are disjunctions of
+// literals. Variables are numbered from 1 and a negative
+// literal is the negation of its variable, like in DIMACS
+type CNF struct {
+	// Vars has the names of the predicate variables, the one at
+	// index i is numbered i+1. Variables after them are
+	// auxiliary
+	Vars    []string
+	NumVars int
+	Clauses [][]int
+}
+
+// CardEncoding selects how cardinality constraints are
+// translated to clauses
+type CardEncoding int
+
+const (
+	// SequentialCounter uses O(n·k) auxiliary variables, counting
+	// the true operands from left to right
+	SequentialCounter CardEncoding = iota
+	// Totalizer uses a balanced tree of unary adders, with
+	// O(n·log n) variables and O(n·k) clauses
+	Totalizer
+)
+
+// ToCNF returns an equisatisfiable CNF for p, using the Tseitin
+// transformation and enc for cardinality constraints. Every
+// auxiliary variable is defined by an equivalence, so each
+// assignment of the variables in p extends to exactly one
+// assignment of the CNF, that satisfies it iff p is true. p
+// must be propositional, as described in UnsupportedErr
+func ToCNF(p *Predicate, enc CardEncoding) (c *CNF, e error) {
+	x, e := propositional(p)
+	if e == nil {
+		c = &CNF{Vars: Vars(x)}
+		c.NumVars = len(c.Vars)
+		t := &tseitin{cnf: c, enc: enc, vars: make(map[string]int)}
+		for i, v := range c.Vars {
+			t.vars[v] = i + 1
+		}
+		for _, q := range conjuncts(x) {
+			c.Clauses = append(c.Clauses, []int{t.lit(q)})
+		}
+	}
+	return
+}
+
+// propositional returns p with its let expressions expanded and
+// its quantifiers grounded, which leaves only boolean
+// operators, unless p has comparisons
+func propositional(p *Predicate) (x *Predicate, e error) {
+	// let expressions can't be expanded with errors
+	x, _ = Expand(p, nil)
+	x, e = Ground(x, nil)
+	if e == nil && hasOperator(x, isComparison) {
+		e = &UnsupportedErr{Operator: "comparison"}
+	}
+	return
+}
+
+// UnsupportedErr is returned when a predicate has operators
+// that can't be translated. The functions that need a
+// propositional predicate, like ToCNF, Compile and the Solver,
+// expand its let expressions and ground its quantifiers, which
+// must range over explicit elements, and return UnsupportedErr
+// when it has comparisons
+type UnsupportedErr struct {
+	Operator string
+}
+
+func (u *UnsupportedErr) Error() (s string) {
+	s = fmt.Sprintf("Unsupported %s", u.Operator)
+	return
+}
+
+// WriteDIMACS writes c in DIMACS format, with the names of the
+// variables as comments
+func WriteDIMACS(w io.Writer, c *CNF) (e error) {
+	var sb strings.Builder
+	for i, v := range c.Vars {
+		fmt.Fprintf(&sb, "c %d %s\n", i+1, v)
+	}
+	fmt.Fprintf(&sb, "p cnf %d %d\n", c.NumVars, len(c.Clauses))
+	for _, cl := range c.Clauses {
+		for _, l := range cl {
+			fmt.Fprintf(&sb, "%d ", l)
+		}
+		sb.WriteString("0\n")
+	}
+	_, e = io.WriteString(w, sb.String())
+	return
+}
+
+func conjuncts(p *Predicate) (cs []*Predicate) {
+	if p.Operator == AndOp {
+		cs = append(conjuncts(p.A), conjuncts(p.B)...)
+	} else {
+		cs = []*Predicate{p}
+	}
+	return
+}
+
+type tseitin struct {
+	cnf  *CNF
+	enc  CardEncoding
+	vars map[string]int
+	// top is the variable that is always true, 0 until needed
+	top int
+}
+
+func (t *tseitin) fresh() (v int) {
+	t.cnf.NumVars = t.cnf.NumVars + 1
+	v = t.cnf.NumVars
+	return
+}
+
+func (t *tseitin) add(cl ...int) {
+	t.cnf.Clauses = append(t.cnf.Clauses, cl)
+}
+
+func (t *tseitin) truth() (l int) {
+	if t.top == 0 {
+		t.top = t.fresh()
+		t.add(t.top)
+	}
+	l = t.top
+	return
+}
+
+func (t *tseitin) isConst(l int, v bool) (ok bool) {
+	ok = t.top != 0 && ((v && l == t.top) || (!v && l == -t.top))
+	return
+}
+
+// lit returns a literal equivalent to p
+func (t *tseitin) lit(p *Predicate) (l int) {
+	switch p.Operator {
+	case Term:
+		if p.String == TrueStr {
+			l = t.truth()
+		} else if p.String == FalseStr {
+			l = -t.truth()
+		} else {
+			l = t.vars[p.String]
+		}
+	case AtomOp:
+		// an atom is a variable named by its string
+		l = t.vars[String(p)]
+	case NotOp:
+		l = -t.lit(p.B)
+	case AndOp:
+		l = t.and(t.lit(p.A), t.lit(p.B))
+	case OrOp:
+		l = t.or(t.lit(p.A), t.lit(p.B))
+	case NandOp:
+		l = t.or(-t.lit(p.A), -t.lit(p.B))
+	case NorOp:
+		l = -t.or(t.lit(p.A), t.lit(p.B))
+	case ImpliesOp:
+		l = t.or(-t.lit(p.A), t.lit(p.B))
+	case FollowsOp:
+		l = t.or(t.lit(p.A), -t.lit(p.B))
+	case EquivalesOp:
+		l = t.equiv(t.lit(p.A), t.lit(p.B))
+	case NotEquivalesOp, XorOp:
+		l = -t.equiv(t.lit(p.A), t.lit(p.B))
+	case IfOp:
+		l = t.ite(t.lit(p.A), t.lit(p.B), t.lit(p.C))
+	case AtMostOp, AtLeastOp, ExactlyOp:
+		l = t.card(p)
+	}
+	return
+}
+
+// or returns a literal equivalent to the disjunction of ls
+func (t *tseitin) or(ls ...int) (l int) {
+	rest := make([]int, 0, len(ls))
+	for _, x := range ls {
+		if t.isConst(x, true) {
+			l = x
+		} else if !t.isConst(x, false) {
+			rest = append(rest, x)
+		}
+	}
+	if l == 0 && len(rest) == 0 {
+		l = -t.truth()
+	} else if l == 0 && len(rest) == 1 {
+		l = rest[0]
+	} else if l == 0 {
+		l = t.fresh()
+		// l ≡ x₀ ∨ … ∨ xₙ
+		t.add(append([]int{-l}, rest...)...)
+		for _, x := range rest {
+			t.add(l, -x)
+		}
+	}
+	return
+}
+
+func (t *tseitin) and(ls ...int) (l int) {
+	ns := make([]int, len(ls))
+	for i, x := range ls {
+		ns[i] = -x
+	}
+	l = -t.or(ns...)
+	return
+}
+
+func (t *tseitin) equiv(a, b int) (l int) {
+	l = t.fresh()
+	t.add(-l, -a, b)
+	t.add(-l, a, -b)
+	t.add(l, a, b)
+	t.add(l, -a, -b)
+	return
+}
+
+func (t *tseitin) ite(c, a, b int) (l int) {
+	l = t.fresh()
+	t.add(-l, -c, a)
+	t.add(-l, c, b)
+	t.add(l, -c, -a)
+	t.add(l, c, -b)
+	return
+}
+
+func (t *tseitin) card(p *Predicate) (l int) {
+	n := len(p.Args)
+	// m is the greatest count needed, counting more doesn't
+	// change the result
+	m := p.K + 1
+	if m > n {
+		m = n
+	}
+	xs := make([]int, n)
+	for i, a := range p.Args {
+		xs[i] = t.lit(a)
+	}
+	var s []int
+	if m < 0 {
+		s = []int{t.truth()}
+	} else if t.enc == Totalizer {
+		s = t.totalizer(xs, m)
+	} else {
+		s = t.counter(xs, m)
+	}
+	// s[j] ≡ at least j operands are true, for j ≤ m
+	atLeast := func(j int) (r int) {
+		if j <= 0 {
+			r = t.truth()
+		} else if j >= len(s) {
+			r = -t.truth()
+		} else {
+			r = s[j]
+		}
+		return
+	}
+	if p.Operator == AtLeastOp {
+		l = atLeast(p.K)
+	} else if p.Operator == AtMostOp {
+		l = -atLeast(p.K + 1)
+	} else {
+		l = t.and(atLeast(p.K), -atLeast(p.K+1))
+	}
+	return
+}
+
+// counter returns the outputs of a sequential counter over xs,
+// where the count at step i is at least j when it was at least
+// j at step i-1, or it was at least j-1 and xs[i] is true
+func (t *tseitin) counter(xs []int, m int) (s []int) {
+	s = []int{t.truth()}
+	for i, x := range xs {
+		k := i + 1
+		if k > m {
+			k = m
+		}
+		next := make([]int, k+1)
+		next[0] = s[0]
+		for j := 1; j <= k; j++ {
+			prev := -t.truth()
+			if j < len(s) {
+				prev = s[j]
+			}
+			next[j] = t.or(prev, t.and(x, s[j-1]))
+		}
+		s = next
+	}
+	return
+}
+
+// totalizer returns the unary count of xs, up to m, merging the
+// counts of both halves with a fresh variable for each output
+func (t *tseitin) totalizer(xs []int, m int) (s []int) {
+	if len(xs) == 0 {
+		s = []int{t.truth()}
+	} else if len(xs) == 1 {
+		s = []int{t.truth(), xs[0]}
+	} else {
+		h := len(xs) / 2
+		a, b := t.totalizer(xs[:h], m), t.totalizer(xs[h:], m)
+		k := len(a) + len(b) - 2
+		if k > m {
+			k = m
+		}
+		s = make([]int, k+1)
+		s[0] = t.truth()
+		for j := 1; j <= k; j++ {
+			s[j] = t.fresh()
+		}
+		// at returns the literal of at least i in c, which is
+		// false past its end
+		at := func(c []int, i int) (l int) {
+			l = -t.truth()
+			if i < len(c) {
+				l = c[i]
+			}
+			return
+		}
+		for i := 0; i != len(a); i++ {
+			for j := 0; j != len(b) && i+j <= k; j++ {
+				if i+j != 0 {
+					// at least i in a ∧ at least j in b ⇒ s[i+j]
+					t.clause(-a[i], -b[j], s[i+j])
+				}
+				if i+j != k {
+					// s[i+j+1] ⇒ at least i+1 in a ∨ at least j+1 in b
+					t.clause(-s[i+j+1], at(a, i+1), at(b, j+1))
+				}
+			}
+		}
+	}
+	return
+}
+
+// clause adds the clause with the literals in ls that aren't
+// false, unless one is true
+func (t *tseitin) clause(ls ...int) {
+	var cl []int
+	sat := false
+	for _, l := range ls {
+		sat = sat || t.isConst(l, true)
+		if !t.isConst(l, false) {
+			cl = append(cl, l)
+		}
+	}
+	if !sat {
+		t.add(cl...)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestToCNF(t *testing.T) {
+	ps := []string{
+		"A",
+		"¬A ∧ (B ∨ C)",
+		"A ⇒ B ≡ ¬C",
+		"A ⇐ B ⊕ C",
+		"(A ↑ B) ∧ (B ↓ C)",
+		"if A then B else C ∨ D",
+		"let x := A ∨ B in x ∧ ¬x",
+		"true ∧ (A ∨ false)",
+		"atmost(1; A, B, C, D)",
+		"atmost(2; A, B ∧ C, D, E)",
+		"atleast(2; A, B, C, D, E)",
+		"atleast(0; A, B)",
+		"exactly(1; A, B, C)",
+		"exactly(3; A, B, C, D, E) ∨ exactly(0; A, B)",
+		"¬exactly(2; A, ¬B, C, D)",
+		"atmost(5; A, B) ∧ atleast(3; A, B)",
+	}
+	for _, enc := range []CardEncoding{SequentialCounter, Totalizer} {
+		inf := func(i int) {
+			p, e := Parse(strings.NewReader(ps[i]))
+			require.NoError(t, e, "At %d", i)
+			c, e := ToCNF(p, enc)
+			require.NoError(t, e)
+			require.Equal(t, Vars(p), c.Vars)
+			forallAssign(c.Vars, func(m map[string]bool) {
+				v := Reduce(p, assignInterp(m)).String == TrueStr
+				require.Equal(t, v, propagates(c, m), "At %d %v", i, m)
+			})
+		}
+		alg.Forall(inf, len(ps))
+	}
+}
+
+func TestTotalizerSize(t *testing.T) {
+	// n·(log₂ n + 1) variables bound the totalizer of 256 operands
+	p := &Predicate{Operator: AtLeastOp, K: 128}
+	for i := 0; i != 256; i++ {
+		p.Args = append(p.Args, NewTerm(fmt.Sprintf("x%d", i)))
+	}
+	c, e := ToCNF(p, Totalizer)
+	require.NoError(t, e)
+	require.True(t, c.NumVars <= 256*9, "%d variables", c.NumVars)
+}
+
+func TestWriteDIMACS(t *testing.T) {
+	p, e := Parse(strings.NewReader("A ∧ ¬B"))
+	require.NoError(t, e)
+	c, e := ToCNF(p, Totalizer)
+	require.NoError(t, e)
+	var sb strings.Builder
+	e = WriteDIMACS(&sb, c)
+	require.NoError(t, e)
+	require.Equal(t, "c 1 A\nc 2 B\np cnf 2 2\n1 0\n-2 0\n",
+		sb.String())
+}
+
+// forallAssign calls f with every assignment of vs
+func forallAssign(vs []string, f func(map[string]bool)) {
+	for n := 0; n != 1<<len(vs); n++ {
+		m := make(map[string]bool)
+		for i, v := range vs {
+			m[v] = n&(1<<i) != 0
+		}
+		f(m)
+	}
+}
+
+func assignInterp(m map[string]bool) (n NameBool) {
+	n = func(name string) (v, ok bool) {
+		v, ok = m[name]
+		if !ok {
+			v, ok = constInterp(name)
+		}
+		return
+	}
+	return
+}
+
+// propagates returns whether unit propagation, after assigning
+// the variables in c according to m, finishes without
+// conflicts and satisfies every clause. Since the auxiliary
+// variables are defined by equivalences, propagation assigns
+// all of them
+func propagates(c *CNF, m map[string]bool) (ok bool) {
+	val := make([]int, c.NumVars+1)
+	for i, v := range c.Vars {
+		val[i+1] = -1
+		if m[v] {
+			val[i+1] = 1
+		}
+	}
+	litVal := func(l int) (r int) {
+		if l < 0 {
+			r = -val[-l]
+		} else {
+			r = val[l]
+		}
+		return
+	}
+	ok, changed := true, true
+	for ok && changed {
+		changed = false
+		for _, cl := range c.Clauses {
+			sat, free, unk := false, 0, 0
+			for _, l := range cl {
+				sat = sat || litVal(l) == 1
+				if litVal(l) == 0 {
+					free, unk = l, unk+1
+				}
+			}
+			if !sat && unk == 0 {
+				ok = false
+			} else if !sat && unk == 1 {
+				if free < 0 {
+					val[-free] = -1
+				} else {
+					val[free] = 1
+				}
+				changed = true
+			}
+		}
+	}
+	for i := 1; ok && i != len(val); i++ {
+		ok = val[i] != 0
+	}
+	return
+}
@@ -0,0 +1,107 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+// Cofactor returns p reduced with the variable name replaced by
+// v, which is a term or an atom like p(a). The quantifiers over
+// named domains must be grounded before, since they aren't
+// reduced
+func Cofactor(p *Predicate, name string, v bool) (r *Predicate) {
+	r = ReduceEnv(p, MapEnv{name: v})
+	return
+}
+
+// Exists returns a predicate equivalent to p with the variables
+// in vars quantified existentially, without them. When p is
+// propositional, it's computed by quantifying the BDD of p,
+// which gives the simplest result. Otherwise it's the Shannon
+// expansion p[x := false] ∨ p[x := true] for each variable x,
+// reduced
+func Exists(p *Predicate, vars ...string) (r *Predicate) {
+	r = quantifyVars(p, vars, true)
+	return
+}
+
+// Forall returns a predicate equivalent to p with the variables
+// in vars quantified universally, like Exists but with the
+// conjunction p[x := false] ∧ p[x := true]
+func Forall(p *Predicate, vars ...string) (r *Predicate) {
+	r = quantifyVars(p, vars, false)
+	return
+}
+
+func quantifyVars(p *Predicate, vars []string, exists bool) (r *Predicate) {
+	x, e := propositional(p)
+	var b *BDD
+	if e == nil {
+		b, e = Compile(x)
+	}
+	if e == nil {
+		qs := make(map[int]bool)
+		for _, v := range vars {
+			if l, ok := b.levels[v]; ok {
+				qs[l] = true
+			}
+		}
+		r = b.predicate(b.quantify(b.root, qs, exists), varNodes(x))
+	} else {
+		r = shannon(p, vars, exists)
+	}
+	return
+}
+
+// shannon returns p with the variables in vars replaced by the
+// disjunction of its cofactors when exists is true, and by
+// their conjunction otherwise
+func shannon(p *Predicate, vars []string, exists bool) (r *Predicate) {
+	op := AndOp
+	if exists {
+		op = OrOp
+	}
+	r = ReduceEnv(p, MapEnv{})
+	for _, x := range vars {
+		r = ReduceEnv(&Predicate{
+			Operator: op,
+			A:        Cofactor(r, x, false),
+			B:        Cofactor(r, x, true),
+		}, MapEnv{})
+	}
+	return
+}
+
+// varNodes returns the terms and atoms in p, by the names of
+// the variables they are
+func varNodes(p *Predicate) (m map[string]*Predicate) {
+	m = make(map[string]*Predicate)
+	var walk func(*Predicate)
+	walk = func(q *Predicate) {
+		if q.Operator == Term {
+			m[q.String] = q
+		} else if q.Operator == AtomOp {
+			m[String(q)] = q
+		}
+		for _, c := range children(q) {
+			walk(c)
+		}
+	}
+	walk(p)
+	return
+}
@@ -0,0 +1,126 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestCofactor(t *testing.T) {
+	ps := []struct {
+		pred, name string
+		v          bool
+		cof        string
+	}{
+		{"A ∧ B", "A", true, "B"},
+		{"A ∧ B", "A", false, "false"},
+		{"A ⇒ B ∨ C", "B", false, "A ⇒ C"},
+		{"let x := A in x ∨ B", "A", false, "B"},
+		{"let A := B in A ∨ C", "A", true, "B ∨ C"},
+		{"∀ x ∈ {a, b} : p(x)", "p(a)", true, "p(b)"},
+		{"age > 3 ∧ A", "A", true, "age > 3"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		r := Cofactor(p, ps[i].name, ps[i].v)
+		require.Equal(t, ps[i].cof, String(r), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestExistsForall(t *testing.T) {
+	ps := []struct {
+		pred   string
+		vars   []string
+		ex, fa string
+	}{
+		{"A ∧ B", []string{"A"}, "B", "false"},
+		{"A ∨ B", []string{"B"}, "true", "A"},
+		{"(A ⇒ B) ∧ (B ⇒ C)", []string{"B"}, "A ⇒ C", "¬A ∧ C"},
+		{"exactly(1; A, B, C)", []string{"A", "C"}, "true", "false"},
+		{"∀ x ∈ {a, b} : p(x) ∨ q", []string{"p(a)"}, "p(b) ∨ q", "q"},
+		{"A ≡ B ≡ C", []string{"A", "B"}, "true", "false"},
+		{"A ∧ B", nil, "A ∧ B", "A ∧ B"},
+		{"A ⊕ B ⊕ C ⊕ D", []string{"D"}, "true", "false"},
+		{"(A ⊕ B ⊕ C ⊕ D) ∧ E", []string{"E"},
+			"let n1 := if C then ¬D else D in " +
+				"let n2 := if C then D else ¬D in " +
+				"if A then if B then n1 else n2 else if B then n2 else n1",
+			"false"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		ex, fa := Exists(p, ps[i].vars...), Forall(p, ps[i].vars...)
+		require.Equal(t, ps[i].ex, String(ex), "At %d", i)
+		require.Equal(t, ps[i].fa, String(fa), "At %d", i)
+		for _, v := range ps[i].vars {
+			require.NotContains(t, Vars(ex), v, "At %d", i)
+			require.NotContains(t, Vars(fa), v, "At %d", i)
+		}
+		// the quantified variables take every value for each
+		// assignment of the rest
+		x, e := Ground(p, nil)
+		require.NoError(t, e)
+		forallAssign(Vars(x), func(m map[string]bool) {
+			some, all := false, true
+			forallAssign(ps[i].vars, func(q map[string]bool) {
+				n := make(map[string]bool)
+				for k, v := range m {
+					n[k] = v
+				}
+				for k, v := range q {
+					n[k] = v
+				}
+				v := Reduce(x, assignInterp(n)).String == TrueStr
+				some, all = some || v, all && v
+			})
+			require.Equal(t, some,
+				Reduce(ex, assignInterp(m)).String == TrueStr, "At %d", i)
+			require.Equal(t, all,
+				Reduce(fa, assignInterp(m)).String == TrueStr, "At %d", i)
+		})
+	}
+	alg.Forall(inf, len(ps))
+
+	// the shared decisions are named instead of repeated
+	vs := make([]string, 40)
+	for i := range vs {
+		vs[i] = fmt.Sprintf("x%d", i)
+	}
+	p, e := Parse(strings.NewReader("(" + strings.Join(vs, " ⊕ ") +
+		") ∧ n1"))
+	require.NoError(t, e)
+	ex := Exists(p, "n1")
+	require.Equal(t, 2*len(vs)-6, strings.Count(String(ex), LetOp+" "))
+	require.NotContains(t, Vars(ex), "n1")
+
+	// predicates with comparisons are expanded and reduced
+	p, e = Parse(strings.NewReader("(x > 1 ∨ A) ∧ (B ∨ ¬A)"))
+	require.NoError(t, e)
+	require.Equal(t, "x > 1 ∨ B", String(Exists(p, "A")))
+	require.Equal(t, "x > 1 ∧ B", String(Forall(p, "A")))
+}
@@ -0,0 +1,221 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Env gives the values of the variables in a predicate, the
+// boolean ones of terms and the typed ones of comparisons,
+// which are numbers or strings
+type Env interface {
+	Bool(name string) (v, ok bool)
+	Value(name string) (v any, ok bool)
+}
+
+// Bool returns the value n gives to name
+func (n NameBool) Bool(name string) (v, ok bool) {
+	v, ok = n(name)
+	return
+}
+
+// Value doesn't return values, since a NameBool is the Env of
+// predicates without comparisons
+func (n NameBool) Value(name string) (v any, ok bool) {
+	return
+}
+
+// MapEnv is an Env with the values in a map, where the booleans
+// are the values of terms and the rest those of comparison
+// variables. It defines the constants true and false
+type MapEnv map[string]any
+
+// Bool returns the boolean value of name
+func (m MapEnv) Bool(name string) (v, ok bool) {
+	v, ok = m[name].(bool)
+	if !ok && (name == TrueStr || name == FalseStr) {
+		v, ok = name == TrueStr, true
+	}
+	return
+}
+
+// Value returns the value of name when it isn't boolean
+func (m MapEnv) Value(name string) (v any, ok bool) {
+	v, ok = m[name]
+	if _, isBool := v.(bool); isBool {
+		v, ok = nil, false
+	}
+	return
+}
+
+func reduceCompare(p, r *Predicate, itp Env) (ok bool) {
+	a, b := operand(p.A, itp), operand(p.B, itp)
+	ok = a.Operator != VarOp && b.Operator != VarOp
+	if ok {
+		*r = *constant(compare(p.Operator, a, b))
+	} else {
+		r.Operator, r.A, r.B = p.Operator, a, b
+	}
+	return
+}
+
+func reduceMember(p, r *Predicate, itp Env) (ok bool) {
+	a := operand(p.A, itp)
+	ok = a.Operator != VarOp
+	member := false
+	for i := 0; ok && !member && i != len(p.Args); i++ {
+		member = compare(EqOp, a, p.Args[i])
+	}
+	if ok {
+		*r = *constant(member)
+	} else {
+		r.Operator, r.A, r.Args = InOp, a, p.Args
+	}
+	return
+}
+
+// operand returns the value of p, a number or a string, when
+// it's known, otherwise a copy of the variable p
+func operand(p *Predicate, itp Env) (r *Predicate) {
+	r = &Predicate{Operator: p.Operator, String: p.String}
+	if p.Operator == VarOp {
+		v, ok := itp.Value(p.String)
+		if l := literal(v); ok && l != nil {
+			r = l
+		}
+	}
+	return
+}
+
+// literal returns v as a number or a string, or nil when it's
+// neither
+func literal(v any) (r *Predicate) {
+	x := reflect.ValueOf(v)
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		r = NewNumber(float64(x.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		r = NewNumber(float64(x.Uint()))
+	case reflect.Float32, reflect.Float64:
+		r = NewNumber(x.Float())
+	case reflect.String:
+		r = NewString(x.String())
+	}
+	return
+}
+
+// NewNumber returns the number f as an operand of comparisons
+func NewNumber(f float64) (p *Predicate) {
+	p = &Predicate{
+		Operator: NumberOp,
+		String:   strconv.FormatFloat(f, 'f', -1, 64),
+	}
+	return
+}
+
+// NewString returns s as an operand of comparisons
+func NewString(s string) (p *Predicate) {
+	p = &Predicate{Operator: StringOp, String: s}
+	return
+}
+
+// compare compares the values a and b. Values of different
+// types are only different
+func compare(op string, a, b *Predicate) (ok bool) {
+	var c int
+	same := a.Operator == b.Operator
+	if same && a.Operator == NumberOp {
+		x, _ := strconv.ParseFloat(a.String, 64)
+		y, _ := strconv.ParseFloat(b.String, 64)
+		if x < y {
+			c = -1
+		} else if x > y {
+			c = 1
+		}
+	} else if same && a.String < b.String {
+		c = -1
+	} else if same && a.String > b.String {
+		c = 1
+	}
+	switch op {
+	case EqOp:
+		ok = same && c == 0
+	case NeOp:
+		ok = !same || c != 0
+	case LtOp:
+		ok = same && c < 0
+	case LeOp:
+		ok = same && c <= 0
+	case GtOp:
+		ok = same && c > 0
+	case GeOp:
+		ok = same && c >= 0
+	}
+	return
+}
+
+func constant(v bool) (r *Predicate) {
+	if v {
+		r = True()
+	} else {
+		r = False()
+	}
+	return
+}
+
+// valueString returns the operand p as it's written, with
+// strings quoted
+func valueString(p *Predicate) (r string) {
+	if p.Operator == StringOp {
+		r = strconv.Quote(p.String)
+	} else if p.Operator == VarOp {
+		r = quoteName(p.String)
+	} else {
+		r = p.String
+	}
+	return
+}
+
+func isComparison(op string) (ok bool) {
+	ok = op == EqOp || op == NeOp || op == LtOp || op == LeOp ||
+		op == GtOp || op == GeOp || op == InOp
+	return
+}
+
+func isValue(op string) (ok bool) {
+	ok = op == VarOp || op == NumberOp || op == StringOp
+	return
+}
+
+func validValue(p *Predicate) (ok bool) {
+	ok = p != nil && isValue(p.Operator) && p.A == nil && p.B == nil
+	if ok && p.Operator == VarOp {
+		ok = p.String != ""
+	} else if ok && p.Operator == NumberOp {
+		_, e := strconv.ParseFloat(p.String, 64)
+		ok = e == nil
+	}
+	return
+}
@@ -0,0 +1,135 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseComparison(t *testing.T) {
+	ps := []struct {
+		pred, str string
+	}{
+		{"age ≥ 18", "age ≥ 18"},
+		{`country = "CU"`, `country = "CU"`},
+		{"score<0.5 ∧ ¬(x ≠ y)", "score < 0.5 ∧ ¬(x ≠ y)"},
+		{`tag ∈ {"a","b"}`, `tag ∈ {"a", "b"}`},
+		{"x ∈ {}", "x ∈ {}"},
+		{`"a\"b\n" ≤ name`, `"a\"b\n" ≤ name`},
+		{"-3 > t ∨ t ≤ -2.25", "-3 > t ∨ t ≤ -2.25"},
+		{"atleast(1; a > 1, b < 2)", "atleast(1; a > 1, b < 2)"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid(), "At %d", i)
+		require.Equal(t, ps[i].str, String(p), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	p, e := Parse(strings.NewReader(`"a\"b\n" ≤ name`))
+	require.NoError(t, e)
+	require.Equal(t, StringOp, p.A.Operator)
+	require.Equal(t, VarOp, p.B.Operator)
+
+	errs := []struct {
+		pred string
+		e    error
+	}{
+		{"18 ∧ A", &NotRecognizedErr{String: AndOp,
+			Expecting: []string{EqOp, NeOp, LtOp, LeOp, GtOp, GeOp, InOp}}},
+		{"x = ∧", &NotRecognizedErr{String: AndOp,
+			Expecting: []string{Number, StringLit}}},
+		{`x = - "a"`, &NotRecognizedErr{String: `"a"`,
+			Expecting: []string{Number}}},
+		{`x ∈ {y}`, &NotRecognizedErr{String: "y",
+			Expecting: []string{Number, StringLit}}},
+		{`x = "abc`, &NotRecognizedErr{String: `"abc`,
+			Expecting: []string{StringLit}}},
+		{"atmost(1.5; A)", &NotRecognizedErr{String: "1.5",
+			Expecting: []string{Number}}},
+	}
+	inf = func(i int) {
+		_, e := Parse(strings.NewReader(errs[i].pred))
+		require.Equal(t, errs[i].e, e, "At %d", i)
+	}
+	alg.Forall(inf, len(errs))
+}
+
+func TestReduceEnv(t *testing.T) {
+	env := MapEnv{
+		"age":     21,
+		"country": "CU",
+		"score":   0.75,
+		"admin":   true,
+		"limit":   uint8(3),
+	}
+	ps := [][]string{
+		{"age ≥ 18", "true"},
+		{"age < 18 ∨ admin", "true"},
+		{`country = "CU" ∧ x`, "x"},
+		{`country ≠ "CU"`, "false"},
+		{`country < "DE"`, "true"},
+		{"score < 0.5", "false"},
+		{"score = 0.75", "true"},
+		{"weight > limit", "weight > 3"},
+		{"age = country", "false"},
+		{"age ≠ country", "true"},
+		{`age ≤ "21"`, "false"},
+		{"other ≥ 1 ∧ admin", "other ≥ 1"},
+		{`country ∈ {"CU", "US"}`, "true"},
+		{`country ∈ {"ES"}`, "false"},
+		{`age ∈ {}`, "false"},
+		{`tag ∈ {"a", "b"}`, `tag ∈ {"a", "b"}`},
+		{"-1 < limit", "true"},
+		{"atmost(1; age > 18, score < 0.5, y)", "¬y"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i][0]))
+		require.NoError(t, e, "At %d", i)
+		r := ReduceEnv(p, env)
+		require.True(t, r.Valid(), "At %d", i)
+		require.Equal(t, ps[i][1], String(r), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	// a NameBool has no values
+	p, e := Parse(strings.NewReader("age ≥ 18 ∧ true"))
+	require.NoError(t, e)
+	require.Equal(t, "age ≥ 18", String(Reduce(p, constInterp)))
+}
+
+func TestMapEnv(t *testing.T) {
+	m := MapEnv{"a": true, "n": 1}
+	v, ok := m.Bool("a")
+	require.True(t, v && ok)
+	_, ok = m.Bool("n")
+	require.False(t, ok)
+	v, ok = m.Bool(FalseStr)
+	require.True(t, !v && ok)
+	_, ok = m.Value("a")
+	require.False(t, ok)
+	x, ok := m.Value("n")
+	require.True(t, ok)
+	require.Equal(t, 1, x)
+}
@@ -0,0 +1,117 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"sort"
+)
+
+// UnsatCore returns the indexes, in increasing order, of a
+// minimal subset of ps that is unsatisfiable, which becomes
+// satisfiable when any of them is removed. It's empty when ps
+// is satisfiable. The predicates are solved together, each one
+// enabled by an assumption, and the subset where the solver
+// finds the conflict is reduced by removing one predicate at a
+// time. The predicates must be propositional, like the ones of
+// a Solver
+func UnsatCore(ps []*Predicate) (core []int, e error) {
+	s, sel, e := selectorCNF(ps)
+	var ok bool
+	var fc []int
+	if e == nil {
+		ok, fc = s.solve(sel)
+	}
+	if e == nil && !ok {
+		in := func(xs []int) (r []int) {
+			// the indexes of the selectors in fc
+			m := make(map[int]bool)
+			for _, l := range fc {
+				m[l] = true
+			}
+			for _, i := range xs {
+				if m[sel[i]] {
+					r = append(r, i)
+				}
+			}
+			return
+		}
+		all := make([]int, len(ps))
+		for i := range all {
+			all[i] = i
+		}
+		core = in(all)
+		for k := 0; k != len(core); {
+			rest := append(append([]int{}, core[:k]...), core[k+1:]...)
+			as := make([]int, len(rest))
+			for i, x := range rest {
+				as[i] = sel[x]
+			}
+			ok, fc = s.solve(as)
+			if ok {
+				// core[k] is needed
+				k = k + 1
+			} else {
+				// the needed ones before k are in every core of rest
+				core = in(rest)
+			}
+		}
+	}
+	sort.Ints(core)
+	return
+}
+
+// selectorCNF returns a solver with the clauses of ps, where
+// the ones of ps[i] hold only when sel[i] is true
+func selectorCNF(ps []*Predicate) (s *cdcl, sel []int, e error) {
+	xs := make([]*Predicate, len(ps))
+	names := make(map[string]bool)
+	for i := 0; e == nil && i != len(ps); i++ {
+		xs[i], e = propositional(ps[i])
+		for _, v := range Vars(xs[i]) {
+			names[v] = true
+		}
+	}
+	if e == nil {
+		c := &CNF{}
+		for k := range names {
+			c.Vars = append(c.Vars, k)
+		}
+		sort.Strings(c.Vars)
+		c.NumVars = len(c.Vars)
+		t := &tseitin{cnf: c, vars: make(map[string]int)}
+		for i, v := range c.Vars {
+			t.vars[v] = i + 1
+		}
+		sel = make([]int, len(xs))
+		for i, x := range xs {
+			sel[i] = t.fresh()
+			for _, q := range conjuncts(x) {
+				t.add(-sel[i], t.lit(q))
+			}
+		}
+		s = newCDCL()
+		s.grow(c.NumVars)
+		for _, cl := range c.Clauses {
+			s.addClause(cl)
+		}
+	}
+	return
+}
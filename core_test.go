@@ -0,0 +1,74 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestUnsatCore(t *testing.T) {
+	ps := []struct {
+		rules []string
+		core  []int
+	}{
+		{[]string{"A", "B"}, nil},
+		{[]string{"A", "B", "¬A"}, []int{0, 2}},
+		{[]string{"A ⇒ B", "C", "B ⇒ D", "A", "¬D", "C ∨ D"},
+			[]int{0, 2, 3, 4}},
+		{[]string{"false", "A"}, []int{0}},
+		{[]string{"exactly(1; A, B, C)", "A ∧ B", "D"}, []int{0, 1}},
+		{[]string{"∀ x ∈ {a, b} : p(x)", "¬p(b)", "p(a)"}, []int{0, 1}},
+		// the core is minimal, although not the smallest one
+		{[]string{"A ∨ B", "¬A", "¬B", "A ∧ ¬A"}, []int{0, 1, 2}},
+	}
+	inf := func(i int) {
+		xs := make([]*Predicate, len(ps[i].rules))
+		for j, r := range ps[i].rules {
+			var e error
+			xs[j], e = Parse(strings.NewReader(r))
+			require.NoError(t, e, "At %d", i)
+		}
+		core, e := UnsatCore(xs)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].core, core, "At %d", i)
+		for j := range core {
+			// without any of them the rest is satisfiable
+			var rest []*Predicate
+			for k, x := range core {
+				if k != j {
+					rest = append(rest, xs[x])
+				}
+			}
+			c, e := UnsatCore(rest)
+			require.NoError(t, e, "At %d", i)
+			require.Empty(t, c, "At %d", i)
+		}
+	}
+	alg.Forall(inf, len(ps))
+
+	p, e := Parse(strings.NewReader("a < 1"))
+	require.NoError(t, e)
+	_, e = UnsatCore([]*Predicate{True(), p})
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+}
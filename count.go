@@ -0,0 +1,285 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CountModels returns the amount of assignments of the
+// variables of p that make it true. It counts the models of the
+// CNF of p, splitting it in components without common
+// variables, which are counted separately and cached. p must
+// be propositional, as ToCNF requires
+func CountModels(p *Predicate) (n *big.Int, e error) {
+	c, e := ToCNF(p, SequentialCounter)
+	if e == nil {
+		// each model of p extends to exactly one of the CNF
+		vs := make([]int, c.NumVars)
+		for i := range vs {
+			vs[i] = i + 1
+		}
+		mc := &modelCounter{cache: make(map[string]*big.Int)}
+		n = mc.count(c.Clauses, vs)
+	}
+	return
+}
+
+type modelCounter struct {
+	// cache has the counts of the components already seen,
+	// by their clauses
+	cache map[string]*big.Int
+}
+
+// count returns the amount of assignments of vs that satisfy
+// cls, whose variables are in vs
+func (m *modelCounter) count(cls [][]int, vs []int) (n *big.Int) {
+	cls, assigned, ok := propagate(cls)
+	n = new(big.Int)
+	if ok {
+		used := make(map[int]bool)
+		for _, cl := range cls {
+			for _, l := range cl {
+				used[abs(l)] = true
+			}
+		}
+		free := 0
+		for _, v := range vs {
+			if !used[v] && !assigned[v] {
+				free = free + 1
+			}
+		}
+		// the variables without clauses take both values
+		n.Lsh(big.NewInt(1), uint(free))
+		for _, cp := range components(cls) {
+			n.Mul(n, m.component(cp))
+		}
+	}
+	return
+}
+
+// component returns the amount of models of cls, over its
+// variables, which are connected through its clauses
+func (m *modelCounter) component(cls [][]int) (n *big.Int) {
+	key := clausesKey(cls)
+	n, ok := m.cache[key]
+	if !ok {
+		// the variable with more occurrences is decided first
+		occ := make(map[int]int)
+		var vs []int
+		for _, cl := range cls {
+			for _, l := range cl {
+				if occ[abs(l)] == 0 {
+					vs = append(vs, abs(l))
+				}
+				occ[abs(l)] = occ[abs(l)] + 1
+			}
+		}
+		x := vs[0]
+		for _, v := range vs {
+			if occ[v] > occ[x] {
+				x = v
+			}
+		}
+		t := m.count(append(cls[:len(cls):len(cls)], []int{x}), vs)
+		f := m.count(append(cls[:len(cls):len(cls)], []int{-x}), vs)
+		n = t.Add(t, f)
+		m.cache[key] = n
+	}
+	return
+}
+
+// propagate assigns the literals of unit clauses until there
+// are none, returning the clauses that remain without the
+// assigned variables. It isn't ok when a clause becomes empty
+func propagate(cls [][]int) (r [][]int, assigned map[int]bool,
+	ok bool) {
+	r, assigned, ok = cls, make(map[int]bool), true
+	unit := 0
+	for _, cl := range r {
+		if len(cl) == 1 {
+			unit = cl[0]
+		}
+	}
+	for ok && unit != 0 {
+		assigned[abs(unit)] = true
+		next := make([][]int, 0, len(r))
+		l := unit
+		unit = 0
+		for i := 0; ok && i != len(r); i++ {
+			var ncl []int
+			sat := false
+			for _, x := range r[i] {
+				if x == l {
+					sat = true
+				} else if x != -l {
+					ncl = append(ncl, x)
+				}
+			}
+			ok = sat || len(ncl) != 0
+			if !sat && ok {
+				next = append(next, ncl)
+				if len(ncl) == 1 {
+					unit = ncl[0]
+				}
+			}
+		}
+		r = next
+	}
+	return
+}
+
+// components splits cls in sets of clauses without common
+// variables
+func components(cls [][]int) (cs [][][]int) {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(v int) (r int) {
+		r = v
+		if p, ok := parent[v]; ok && p != v {
+			r = find(p)
+			parent[v] = r
+		}
+		return
+	}
+	for _, cl := range cls {
+		for _, l := range cl[1:] {
+			parent[find(abs(l))] = find(abs(cl[0]))
+		}
+	}
+	idx := make(map[int]int)
+	for _, cl := range cls {
+		root := find(abs(cl[0]))
+		i, ok := idx[root]
+		if !ok {
+			i = len(cs)
+			idx[root] = i
+			cs = append(cs, nil)
+		}
+		cs[i] = append(cs[i], cl)
+	}
+	return
+}
+
+// clausesKey returns a string that is equal for the same sets
+// of clauses
+func clausesKey(cls [][]int) (k string) {
+	ss := make([]string, len(cls))
+	for i, cl := range cls {
+		ls := append([]int{}, cl...)
+		sort.Ints(ls)
+		xs := make([]string, len(ls))
+		for j, l := range ls {
+			xs[j] = strconv.Itoa(l)
+		}
+		ss[i] = strings.Join(xs, " ")
+	}
+	sort.Strings(ss)
+	k = strings.Join(ss, ",")
+	return
+}
+
+func abs(x int) (r int) {
+	r = x
+	if x < 0 {
+		r = -x
+	}
+	return
+}
+
+// EnumerateModels calls f with each assignment of the variables
+// of p that makes it true, until f returns false. When vars
+// isn't empty, the models are projected onto them, calling f
+// once with each assignment of vars that extends to a model.
+// The models are enumerated through the BDD of p, so it must
+// be propositional, as Compile requires
+func EnumerateModels(p *Predicate, f func(map[string]bool) bool,
+	vars ...string) (e error) {
+	x, e := propositional(p)
+	if e == nil {
+		vs := Vars(x)
+		proj := vars
+		if len(proj) == 0 {
+			proj = vs
+		}
+		// the projection variables not in p take both values
+		seen := make(map[string]bool)
+		for _, v := range vs {
+			seen[v] = true
+		}
+		for _, v := range proj {
+			if !seen[v] {
+				seen[v] = true
+				vs = append(vs, v)
+			}
+		}
+		sort.Strings(vs)
+		b := newBDD(vs, 0)
+		b.root = b.build(x)
+		keep := make(map[int]bool)
+		for _, v := range proj {
+			keep[b.levels[v]] = true
+		}
+		qs := make(map[int]bool)
+		var ls []int
+		for i := range vs {
+			if keep[i] {
+				ls = append(ls, i)
+			} else {
+				qs[i] = true
+			}
+		}
+		b.root = b.quantify(b.root, qs, true)
+		b.models(ls, f)
+	}
+	return
+}
+
+// models calls f with the assignments of the variables at the
+// levels ls that make b true, in increasing order of levels,
+// until f returns false. The rest of the variables must not be
+// tested by b
+func (b *BDD) models(ls []int, f func(map[string]bool) bool) {
+	m := make(map[string]bool)
+	cont := true
+	var walk func(int, int)
+	walk = func(n, i int) {
+		if cont && n == 1 && i == len(ls) {
+			r := make(map[string]bool, len(m))
+			for k, v := range m {
+				r[k] = v
+			}
+			cont = f(r)
+		} else if cont && n != 0 && i != len(ls) {
+			lo, hi := b.cofactors(n, ls[i])
+			name := b.Vars[ls[i]]
+			m[name] = false
+			walk(lo, i+1)
+			m[name] = true
+			walk(hi, i+1)
+			delete(m, name)
+		}
+	}
+	walk(b.root, 0)
+}
@@ -0,0 +1,125 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCountModels(t *testing.T) {
+	ps := []string{
+		"A",
+		"true",
+		"false",
+		"A ∨ true",
+		"¬A ∧ (B ∨ C)",
+		"A ⇒ B ≡ ¬C",
+		"(A ∨ B) ∧ (C ∨ D) ∧ (E ⊕ F)",
+		"if A then B else C ∨ D",
+		"atmost(2; A, B ∧ C, D, E)",
+		"exactly(3; A, B, C, D, E) ∨ exactly(0; A, B)",
+		"∀ x ∈ {a, b, c} : p(x) ⇒ q(x)",
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i]))
+		require.NoError(t, e, "At %d", i)
+		n, e := CountModels(p)
+		require.NoError(t, e, "At %d", i)
+		x, e := Ground(p, nil)
+		require.NoError(t, e)
+		sat, ms := 0, make(map[string]bool)
+		forallAssign(Vars(x), func(m map[string]bool) {
+			if Reduce(x, assignInterp(m)).String == TrueStr {
+				sat = sat + 1
+				ms[fmt.Sprint(m)] = true
+			}
+		})
+		require.Equal(t, big.NewInt(int64(sat)), n, "At %d", i)
+		es := make(map[string]bool)
+		e = EnumerateModels(p, func(m map[string]bool) bool {
+			require.Len(t, m, len(Vars(x)), "At %d", i)
+			es[fmt.Sprint(m)] = true
+			return true
+		})
+		require.NoError(t, e)
+		require.Equal(t, ms, es, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	// independent components multiply their counts
+	var sb strings.Builder
+	for i := 0; i != 40; i++ {
+		if i != 0 {
+			sb.WriteString(" ∧ ")
+		}
+		fmt.Fprintf(&sb, "(a%d ∨ b%d)", i, i)
+	}
+	p, e := Parse(strings.NewReader(sb.String()))
+	require.NoError(t, e)
+	n, e := CountModels(p)
+	require.NoError(t, e)
+	three := new(big.Int).Exp(big.NewInt(3), big.NewInt(40), nil)
+	require.Equal(t, three, n)
+
+	p, e = Parse(strings.NewReader("a < 1"))
+	require.NoError(t, e)
+	_, e = CountModels(p)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+}
+
+func TestEnumerateModels(t *testing.T) {
+	p, e := Parse(strings.NewReader("A ∧ (B ∨ C)"))
+	require.NoError(t, e)
+	var ms []map[string]bool
+	all := func(m map[string]bool) bool {
+		ms = append(ms, m)
+		return true
+	}
+	e = EnumerateModels(p, all, "A", "B")
+	require.NoError(t, e)
+	require.Equal(t, []map[string]bool{
+		{"A": true, "B": false},
+		{"A": true, "B": true},
+	}, ms)
+
+	ms = nil
+	e = EnumerateModels(p, all, "C", "D")
+	require.NoError(t, e)
+	require.Equal(t, []map[string]bool{
+		{"C": false, "D": false},
+		{"C": false, "D": true},
+		{"C": true, "D": false},
+		{"C": true, "D": true},
+	}, ms)
+
+	n := 0
+	e = EnumerateModels(p, func(m map[string]bool) bool {
+		n = n + 1
+		return n != 2
+	})
+	require.NoError(t, e)
+	require.Equal(t, 2, n)
+}
@@ -0,0 +1,246 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Defs maps defined names to their predicates, which may refer
+// to other definitions
+type Defs map[string]*Predicate
+
+// CycleErr is returned when a definition depends on itself
+type CycleErr struct {
+	// Names is the sequence of definitions in the cycle,
+	// starting and ending with the same name
+	Names []string
+}
+
+func (c *CycleErr) Error() (s string) {
+	s = "Cyclic definition: " + strings.Join(c.Names, " → ")
+	return
+}
+
+// RedefinedErr is returned when a name is defined twice
+type RedefinedErr struct {
+	Name string
+	Line int
+}
+
+func (r *RedefinedErr) Error() (s string) {
+	s = fmt.Sprintf("'%s' redefined at line %d", r.Name, r.Line)
+	return
+}
+
+// Definitions returns the definitions among the statements,
+// checking there are no cycles between them
+func Definitions(ss []*Statement) (d Defs, e error) {
+	d = make(Defs)
+	for i := 0; e == nil && i != len(ss); i++ {
+		name := ss[i].Definition
+		if _, ok := d[name]; ok && name != "" {
+			e = &RedefinedErr{Name: name, Line: ss[i].Line}
+		} else if name != "" {
+			d[name] = ss[i].Predicate
+		}
+	}
+	if e == nil {
+		e = d.check()
+	}
+	if e != nil {
+		d = nil
+	}
+	return
+}
+
+// check returns a CycleErr if a definition depends on itself
+func (d Defs) check() (e error) {
+	names := make([]string, 0, len(d))
+	for k := range d {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	// done has the definitions without cycles
+	done := make(map[string]bool)
+	var visit func([]string)
+	visit = func(path []string) {
+		name := path[len(path)-1]
+		for i := 0; e == nil && i != len(path)-1; i++ {
+			if path[i] == name {
+				e = &CycleErr{Names: path[i:]}
+			}
+		}
+		deps := Vars(d[name])
+		for i := 0; e == nil && !done[name] && i != len(deps); i++ {
+			if _, ok := d[deps[i]]; ok {
+				visit(append(path[:len(path):len(path)], deps[i]))
+			}
+		}
+		if e == nil {
+			done[name] = true
+		}
+	}
+	for i := 0; e == nil && i != len(names); i++ {
+		visit([]string{names[i]})
+	}
+	return
+}
+
+// Parse parses a predicate and expands the definitions and let
+// expressions in it
+func (d Defs) Parse(rd io.Reader) (p *Predicate, e error) {
+	p, e = Parse(rd)
+	if e == nil {
+		p, e = Expand(p, d)
+	}
+	return
+}
+
+// Expand returns p with its let expressions and the terms
+// defined in d replaced by their predicates, recursively. Let
+// bound names hide definitions with the same name
+func Expand(p *Predicate, d Defs) (r *Predicate, e error) {
+	r, e = expand(p, d, nil, nil)
+	return
+}
+
+// expand uses env for the expanded values of let bound names
+// and path for the definitions being expanded
+func expand(p *Predicate, d Defs, env map[string]*Predicate,
+	path []string) (r *Predicate, e error) {
+	if p.Operator == Term {
+		v, bound := env[p.String]
+		def, defined := d[p.String]
+		if bound {
+			r = v
+		} else if defined {
+			np := append(path[:len(path):len(path)], p.String)
+			for i := 0; e == nil && i != len(path); i++ {
+				if path[i] == p.String {
+					e = &CycleErr{Names: np[i:]}
+				}
+			}
+			if e == nil {
+				// definitions don't see the let bound names
+				r, e = expand(def, d, nil, np)
+			}
+		} else {
+			r = NewTerm(p.String)
+		}
+	} else if p.Operator == LetOp {
+		var v *Predicate
+		v, e = expand(p.A, d, env, path)
+		if e == nil {
+			r, e = expand(p.B, d, bindPred(env, p.String, v), path)
+		}
+	} else if p.Operator == AtomOp {
+		// the parameters of atoms are elements, not predicates
+		r = copyAtom(p)
+	} else if isQuant(p.Operator) {
+		r = &Predicate{Operator: p.Operator, String: p.String,
+			Domain: p.Domain, Args: p.Args}
+		// the quantified name hides definitions and let bound
+		// names
+		r.B, e = expand(p.B, d,
+			bindPred(env, p.String, NewTerm(p.String)), path)
+	} else {
+		r = &Predicate{Operator: p.Operator, String: p.String}
+		if p.A != nil {
+			r.A, e = expand(p.A, d, env, path)
+		}
+		if e == nil && p.B != nil {
+			r.B, e = expand(p.B, d, env, path)
+		}
+		if e == nil && p.C != nil {
+			r.C, e = expand(p.C, d, env, path)
+		}
+		r.K = p.K
+		for i := 0; e == nil && i != len(p.Args); i++ {
+			var a *Predicate
+			a, e = expand(p.Args[i], d, env, path)
+			r.Args = append(r.Args, a)
+		}
+	}
+	return
+}
+
+// bindPred returns a copy of env where name is bound to v
+func bindPred(env map[string]*Predicate, name string,
+	v *Predicate) (r map[string]*Predicate) {
+	r = map[string]*Predicate{name: v}
+	for k, x := range env {
+		if k != name {
+			r[k] = x
+		}
+	}
+	return
+}
+
+func copyAtom(p *Predicate) (r *Predicate) {
+	r = &Predicate{Operator: AtomOp, String: p.String}
+	for _, a := range p.Args {
+		r.Args = append(r.Args, NewTerm(a.String))
+	}
+	return
+}
+
+// Substitute returns p with q in place of the free occurrences
+// of the term name. The let expressions and quantifiers in p
+// must not bind names that are free in q
+func Substitute(p *Predicate, name string,
+	q *Predicate) (r *Predicate) {
+	if p.Operator == Term && p.String == name {
+		r = q
+	} else if p.Operator == Term {
+		r = NewTerm(p.String)
+	} else if p.Operator == AtomOp {
+		r = copyAtom(p)
+	} else {
+		r = &Predicate{Operator: p.Operator, String: p.String,
+			Domain: p.Domain}
+		binds := p.Operator == LetOp || isQuant(p.Operator)
+		if p.A != nil {
+			r.A = Substitute(p.A, name, q)
+		}
+		if p.B != nil && (!binds || p.String != name) {
+			r.B = Substitute(p.B, name, q)
+		} else if p.B != nil {
+			// the let expression or quantifier hides name in its
+			// body
+			r.B = p.B
+		}
+		if p.C != nil {
+			r.C = Substitute(p.C, name, q)
+		}
+		r.K, r.Args = p.K, p.Args
+		if isCard(p.Operator) {
+			r.Args = make([]*Predicate, len(p.Args))
+			for i, a := range p.Args {
+				r.Args[i] = Substitute(a, name, q)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,132 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseLet(t *testing.T) {
+	ps := []struct {
+		pred, str, expanded string
+	}{
+		{"let x := A ∨ B in x ∧ C", "let x := A ∨ B in x ∧ C",
+			"(A ∨ B) ∧ C"},
+		{"D ∧ let x := A in ¬x", "D ∧ (let x := A in ¬x)", "D ∧ ¬A"},
+		{"let x := A in let y := x ∧ B in y ≡ x",
+			"let x := A in let y := x ∧ B in y ≡ x", "A ∧ B ≡ A"},
+		{"let x := y in let y := A in x ∧ y",
+			"let x := y in let y := A in x ∧ y", "y ∧ A"},
+		{"(let x := A in x) ∨ x", "(let x := A in x) ∨ x", "A ∨ x"},
+		{"(let x := A in x ∨ C) ∧ D", "(let x := A in x ∨ C) ∧ D",
+			"(A ∨ C) ∧ D"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid())
+		require.Equal(t, ps[i].str, String(p), "At %d", i)
+		x, e := Expand(p, nil)
+		require.NoError(t, e)
+		require.Equal(t, ps[i].expanded, String(x), "At %d", i)
+		require.Equal(t, String(Reduce(x, constInterp)),
+			String(Reduce(p, constInterp)), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	errs := []struct {
+		pred string
+		e    error
+	}{
+		{"let in := A in B",
+			&NotRecognizedErr{String: InKw, Expecting: []string{Identifier}}},
+		{"let x A in B",
+			&NotRecognizedErr{String: "A", Expecting: []string{AssignOp}}},
+		{"let x := A ∧ B",
+			&NotRecognizedErr{String: string(eof),
+				Expecting: []string{InKw}}},
+		{"let x := A ∧ B in x ∨ C ∧ D", &NotRecognizedErr{String: AndOp,
+			Expecting: []string{OrOp, ImpliesOp, FollowsOp, EquivalesOp,
+				NotEquivalesOp, XorOp}}},
+		{"A ∧ in", &NotRecognizedErr{String: InKw,
+			Expecting: []string{Identifier, OPar}}},
+	}
+	inf = func(i int) {
+		_, e := Parse(strings.NewReader(errs[i].pred))
+		require.Equal(t, errs[i].e, e, "At %d", i)
+	}
+	alg.Forall(inf, len(errs))
+}
+
+func constInterp(name string) (v, ok bool) {
+	v, ok = name == TrueStr, name == TrueStr || name == FalseStr
+	return
+}
+
+func TestDefinitions(t *testing.T) {
+	txt := "def isAdmin := roleAdmin ∨ roleRoot;\n" +
+		"def canWrite := isAdmin ∨ owner;\n" +
+		"canWrite ∧ ¬banned;\n" +
+		"let isAdmin := false in canWrite ∧ isAdmin"
+	ss := ParseAll(strings.NewReader(txt))
+	require.Len(t, ss, 4)
+	require.Equal(t, "isAdmin", ss[0].Definition)
+	require.Equal(t, "canWrite", ss[1].Definition)
+	d, e := Definitions(ss)
+	require.NoError(t, e)
+	require.Len(t, d, 2)
+	x, e := Expand(ss[2].Predicate, d)
+	require.NoError(t, e)
+	require.Equal(t, "(roleAdmin ∨ roleRoot ∨ owner) ∧ ¬banned",
+		String(x))
+	x, e = Expand(ss[3].Predicate, d)
+	require.NoError(t, e)
+	require.Equal(t, "(roleAdmin ∨ roleRoot ∨ owner) ∧ false", String(x))
+
+	x, e = d.Parse(strings.NewReader("isAdmin ⇒ canWrite"))
+	require.NoError(t, e)
+	require.Equal(t, "roleAdmin ∨ roleRoot ⇒ roleAdmin ∨ roleRoot ∨ owner",
+		String(x))
+
+	cyc := "def a := b ∧ c; def b := ¬d; def d := x ∨ b"
+	_, e = Definitions(ParseAll(strings.NewReader(cyc)))
+	require.Equal(t, &CycleErr{Names: []string{"b", "d", "b"}}, e)
+	_, e = Expand(NewTerm("a"), Defs{"a": NewTerm("a")})
+	require.Equal(t, &CycleErr{Names: []string{"a", "a"}}, e)
+
+	red := "def a := b;\n def a := c"
+	_, e = Definitions(ParseAll(strings.NewReader(red)))
+	require.Equal(t, &RedefinedErr{Name: "a", Line: 2}, e)
+	require.Equal(t, "'a' redefined at line 2", e.Error())
+}
+
+func TestSubstitute(t *testing.T) {
+	p, e := Parse(strings.NewReader("x ∧ (let x := A in x ∨ y)"))
+	require.NoError(t, e)
+	r := Substitute(p, "x", True())
+	require.Equal(t, "true ∧ (let x := A in x ∨ y)", String(r))
+	r = Substitute(p, "y", NewTerm("B"))
+	require.Equal(t, "x ∧ (let x := A in x ∨ B)", String(r))
+	require.Equal(t, []string{"A", "x", "y"}, Vars(p))
+}
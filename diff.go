@@ -0,0 +1,238 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EditKind is the kind of change an Edit makes
+type EditKind int
+
+const (
+	Insert EditKind = iota
+	Delete
+	Replace
+)
+
+// Edit is a change of a subtree in the structural difference of
+// two predicates
+type Edit struct {
+	Kind EditKind
+	// Old is the changed subtree, nil for insertions
+	Old *Predicate
+	// New is the subtree after the change, nil for deletions
+	New *Predicate
+}
+
+// String returns the edit as + new, - old or ~ old → new
+func (d *Edit) String() (r string) {
+	if d.Kind == Insert {
+		r = "+ " + String(d.New)
+	} else if d.Kind == Delete {
+		r = "- " + String(d.Old)
+	} else {
+		r = "~ " + String(d.Old) + " → " + String(d.New)
+	}
+	return
+}
+
+// Difference is the structural and semantic difference between
+// an old and a new predicate
+type Difference struct {
+	// Edits transform the tree of the old predicate into the
+	// one of the new predicate
+	Edits []*Edit
+	// Accepted has examples of assignments that make the new
+	// predicate true and the old one false
+	Accepted []map[string]bool
+	// Rejected has examples of assignments that make the new
+	// predicate false and the old one true
+	Rejected []map[string]bool
+}
+
+// diffExamples is the maximum amount of examples in Accepted and
+// in Rejected
+const diffExamples = 5
+
+// Diff returns the difference between old and new. The edits
+// match the operands of chains of ∧ and ∨, and of cardinality
+// constraints, by their longest common subsequence, and the
+// rest of the operands by position. The examples assign every
+// variable of both predicates, so they need propositional
+// predicates. When there are no examples because of that, d
+// has the edits and e is the UnsupportedErr
+func Diff(old, new *Predicate) (d *Difference, e error) {
+	d = &Difference{Edits: diffTree(old, new)}
+	x, e := propositional(old)
+	var y *Predicate
+	if e == nil {
+		y, e = propositional(new)
+	}
+	examples := func(p, q *Predicate) (ms []map[string]bool, e error) {
+		// the models of p ∧ ¬q, assigning the variables of both
+		vs := Vars(&Predicate{Operator: AndOp, A: p, B: q})
+		e = EnumerateModels(&Predicate{Operator: AndOp, A: p,
+			B: negate(q)}, func(m map[string]bool) bool {
+			ms = append(ms, m)
+			return len(ms) != diffExamples
+		}, vs...)
+		return
+	}
+	if e == nil {
+		d.Accepted, e = examples(y, x)
+	}
+	if e == nil {
+		d.Rejected, e = examples(x, y)
+	}
+	if e != nil {
+		d.Accepted, d.Rejected = nil, nil
+	}
+	return
+}
+
+// String returns the edits of d, one per line, followed by the
+// examples of accepted and rejected assignments
+func (d *Difference) String() (r string) {
+	var sb strings.Builder
+	for _, x := range d.Edits {
+		sb.WriteString(x.String() + "\n")
+	}
+	for _, m := range d.Accepted {
+		fmt.Fprintf(&sb, "accepts %s\n", literals(m))
+	}
+	for _, m := range d.Rejected {
+		fmt.Fprintf(&sb, "rejects %s\n", literals(m))
+	}
+	r = sb.String()
+	return
+}
+
+// diffTree returns the edits that transform a into b
+func diffTree(a, b *Predicate) (ds []*Edit) {
+	la, ca, list := diffNode(a)
+	lb, cb, _ := diffNode(b)
+	if key(a) == key(b) {
+		ds = []*Edit{}
+	} else if la != lb || (!list && len(ca) != len(cb)) {
+		ds = []*Edit{{Kind: Replace, Old: a, New: b}}
+	} else if !list {
+		for i := range ca {
+			ds = append(ds, diffTree(ca[i], cb[i])...)
+		}
+	} else {
+		ds = diffList(ca, cb)
+	}
+	return
+}
+
+// diffNode returns the label of p, which is equal for nodes that
+// only differ in their children, the children and whether they
+// are a list, where operands can be inserted and deleted
+func diffNode(p *Predicate) (label string, cs []*Predicate,
+	list bool) {
+	if p.Operator == Term || p.Operator == AtomOp ||
+		isComparison(p.Operator) || isValue(p.Operator) {
+		label = String(p)
+	} else if p.Operator == AndOp || p.Operator == OrOp {
+		label, cs, list = p.Operator, chain(p, p.Operator), true
+	} else if isCard(p.Operator) {
+		label, cs, list = fmt.Sprintf("%s %d", p.Operator, p.K), p.Args,
+			true
+	} else if isQuant(p.Operator) {
+		label = fmt.Sprintf("%s %s %s", p.Operator, p.String,
+			domainString(p))
+		cs = []*Predicate{p.B}
+	} else {
+		label, cs = p.Operator+" "+p.String, children(p)
+	}
+	return
+}
+
+// chain returns the operands of the chain of op at p
+func chain(p *Predicate, op string) (cs []*Predicate) {
+	if p.Operator == op {
+		cs = append(chain(p.A, op), chain(p.B, op)...)
+	} else {
+		cs = []*Predicate{p}
+	}
+	return
+}
+
+// diffList returns the edits that transform the operands as
+// into bs, keeping their longest common subsequence. Between
+// kept operands, the deleted and inserted ones are matched by
+// position and compared recursively
+func diffList(as, bs []*Predicate) (ds []*Edit) {
+	// lcs[i][j] is the length of the longest common subsequence
+	// of as[i:] and bs[j:]
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	ka, kb := make([]string, len(as)), make([]string, len(bs))
+	for i, x := range as {
+		ka[i] = key(x)
+	}
+	for j, x := range bs {
+		kb[j] = key(x)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if ka[i] == kb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] > lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	// dels and ins are the operands between kept ones
+	var dels, ins []*Predicate
+	flush := func() {
+		for k := 0; k < len(dels) || k < len(ins); k++ {
+			if k < len(dels) && k < len(ins) {
+				ds = append(ds, diffTree(dels[k], ins[k])...)
+			} else if k < len(dels) {
+				ds = append(ds, &Edit{Kind: Delete, Old: dels[k]})
+			} else {
+				ds = append(ds, &Edit{Kind: Insert, New: ins[k]})
+			}
+		}
+		dels, ins = nil, nil
+	}
+	i, j := 0, 0
+	for i != len(as) || j != len(bs) {
+		if i != len(as) && j != len(bs) && ka[i] == kb[j] {
+			flush()
+			i, j = i+1, j+1
+		} else if j == len(bs) || (i != len(as) &&
+			lcs[i+1][j] >= lcs[i][j+1]) {
+			dels, i = append(dels, as[i]), i+1
+		} else {
+			ins, j = append(ins, bs[j]), j+1
+		}
+	}
+	flush()
+	return
+}
@@ -0,0 +1,102 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	ps := []struct {
+		old, new string
+		edits    []string
+	}{
+		{"A ∧ B", "A ∧ B", []string{}},
+		{"A ∧ B", "A ∧ B ∧ C", []string{"+ C"}},
+		{"A ∧ B ∧ C", "A ∧ C", []string{"- B"}},
+		{"A ∨ B ∨ C", "A ∨ D ∨ C", []string{"~ B → D"}},
+		{"A ⇒ B ∧ C", "A ⇒ B ∧ ¬C", []string{"~ C → ¬C"}},
+		{"A ⇒ B", "A ≡ B", []string{"~ A ⇒ B → A ≡ B"}},
+		{"(A ⇒ B) ⇒ C", "A ⇒ (B ⇒ C)",
+			[]string{"~ A ⇒ B → A", "~ C → B ⇒ C"}},
+		{"(A ∧ B) ∧ C", "A ∧ (B ∧ C)", []string{}},
+		{"¬(A ∨ B)", "¬(B ∨ A ∨ C)", []string{"- A", "+ A", "+ C"}},
+		{"atleast(1; a, b)", "atleast(1; a, c, b)", []string{"+ c"}},
+		{"atleast(1; a, b)", "atleast(2; a, b)",
+			[]string{"~ atleast(1; a, b) → atleast(2; a, b)"}},
+		{"if A then B else C", "if A then B else D", []string{"~ C → D"}},
+		{"∀ x ∈ {m, n} : p(x)", "∀ x ∈ {m, n} : p(x) ∧ q(x)",
+			[]string{"~ p(x) → p(x) ∧ q(x)"}},
+	}
+	inf := func(i int) {
+		a, e := Parse(strings.NewReader(ps[i].old))
+		require.NoError(t, e)
+		b, e := Parse(strings.NewReader(ps[i].new))
+		require.NoError(t, e)
+		d, e := Diff(a, b)
+		require.NoError(t, e)
+		es := make([]string, len(d.Edits))
+		for j, x := range d.Edits {
+			es[j] = x.String()
+		}
+		require.Equal(t, ps[i].edits, es, "At %d", i)
+		// the examples distinguish the predicates
+		for _, m := range d.Accepted {
+			env := MapEnv(toAny(m))
+			require.Equal(t, TTrue, Eval(b, env, Kleene), "At %d", i)
+			require.Equal(t, TFalse, Eval(a, env, Kleene), "At %d", i)
+		}
+		for _, m := range d.Rejected {
+			env := MapEnv(toAny(m))
+			require.Equal(t, TFalse, Eval(b, env, Kleene), "At %d", i)
+			require.Equal(t, TTrue, Eval(a, env, Kleene), "At %d", i)
+		}
+		equiv := len(ps[i].edits) == 0
+		require.Equal(t, equiv, len(d.Accepted)+len(d.Rejected) == 0,
+			"At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestDifferenceString(t *testing.T) {
+	a, e := Parse(strings.NewReader("admin ∨ owner"))
+	require.NoError(t, e)
+	b, e := Parse(strings.NewReader("admin ∨ (owner ∧ ¬banned)"))
+	require.NoError(t, e)
+	d, e := Diff(a, b)
+	require.NoError(t, e)
+	require.Equal(t, "~ owner → owner ∧ ¬banned\n"+
+		"rejects ¬admin, banned, owner\n", d.String())
+
+	c, e := Parse(strings.NewReader("n < 1"))
+	require.NoError(t, e)
+	d, e = Diff(a, c)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+	require.Equal(t, "~ admin ∨ owner → n < 1\n", d.String())
+	d, e = Diff(c, &Predicate{Operator: AndOp, A: c, B: NewTerm("A")})
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+	require.Equal(t, "~ n < 1 → n < 1 ∧ A\n", d.String())
+	require.Nil(t, d.Accepted)
+	require.Nil(t, d.Rejected)
+}
@@ -0,0 +1,348 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"sort"
+)
+
+// Mark is the role of a subtree in the evaluation of an
+// explained predicate
+type Mark int
+
+const (
+	// Decisive marks the subtrees whose values determine the
+	// value of their parents
+	Decisive Mark = iota
+	// Skipped marks the subtrees that are short-circuited,
+	// since the value of their parents doesn't depend on them
+	Skipped
+)
+
+// Explanation tells why a predicate has a value in an Env
+type Explanation struct {
+	// Value is the value of the predicate in the Env
+	Value Truth
+	// Predicate is the explained predicate, with its let
+	// expressions expanded and its quantifiers over explicit
+	// elements grounded
+	Predicate *Predicate
+	// Marks has the mark of every subtree of Predicate
+	Marks map[*Predicate]Mark
+	// Reasons is the justification of Value, a minimal subset
+	// of the Env that gives the same value, as literals for the
+	// terms and atoms, and equalities for the variables of
+	// comparisons, sorted by name. It's empty when Value is
+	// unknown
+	Reasons []*Predicate
+}
+
+// Explain evaluates p in env like Eval with the Kleene logic,
+// and returns the reasons of its value. The subtrees are marked
+// following a left to right short-circuit evaluation, where
+// only the reasons are known
+func Explain(p *Predicate, env Env) (x *Explanation) {
+	q, e := Expand(p, nil)
+	if e == nil {
+		var g *Predicate
+		g, e = Ground(q, nil)
+		if e == nil {
+			q = g
+		}
+	}
+	x = &Explanation{
+		Value:     Eval(q, env, Kleene),
+		Predicate: q,
+		Marks:     make(map[*Predicate]Mark),
+	}
+	if x.Value != TUnknown {
+		x.mark(q, env)
+		// the variables of the decisive leaves are sufficient,
+		// and dropping the ones that aren't necessary leaves a
+		// minimal subset, since the values of Eval only become
+		// unknown when the Env defines less variables
+		names := make(map[string]bool)
+		for l, m := range x.Marks {
+			if m == Decisive {
+				leafNames(l, names)
+			}
+		}
+		kept := make([]string, 0, len(names))
+		for k := range names {
+			kept = append(kept, k)
+		}
+		sort.Strings(kept)
+		for i := 0; i != len(kept); {
+			rest := append(kept[:i:i], kept[i+1:]...)
+			if Eval(q, restrict(env, rest), Kleene) == x.Value {
+				kept = rest
+			} else {
+				i = i + 1
+			}
+		}
+		sub := restrict(env, kept)
+		x.Marks = make(map[*Predicate]Mark)
+		x.mark(q, sub)
+		x.Reasons = reasons(q, sub, kept)
+	} else {
+		x.mark(q, env)
+	}
+	return
+}
+
+// mark marks p and its subtrees as decisive or skipped,
+// according to their values in env
+func (x *Explanation) mark(p *Predicate, env Env) {
+	x.Marks[p] = Decisive
+	ev := func(q *Predicate) Truth { return Eval(q, env, Kleene) }
+	cs := children(p)
+	if isQuant(p.Operator) {
+		// the quantifiers left are over named domains, and
+		// their bodies aren't evaluated
+		cs = nil
+	}
+	// used has the children whose values determine the value of
+	// p, which are all of them unless all is false
+	used, all := []*Predicate{}, true
+	v := ev(p)
+	if v != TUnknown {
+		switch p.Operator {
+		case AndOp, NandOp, OrOp, NorOp:
+			// the first operand with the absorbing value is enough
+			abs := TFalse
+			if p.Operator == OrOp || p.Operator == NorOp {
+				abs = TTrue
+			}
+			if ev(p.A) == abs {
+				used, all = []*Predicate{p.A}, false
+			} else if ev(p.B) == abs {
+				used, all = []*Predicate{p.B}, false
+			}
+		case ImpliesOp, FollowsOp:
+			// a false antecedent or a true consequent is enough,
+			// and the left one is evaluated first
+			left := TFalse
+			if p.Operator == FollowsOp {
+				left = TTrue
+			}
+			if v == TTrue && ev(p.A) == left {
+				used, all = []*Predicate{p.A}, false
+			} else if v == TTrue {
+				used, all = []*Predicate{p.B}, false
+			}
+		case IfOp:
+			c := ev(p.A)
+			if c == TTrue {
+				used, all = []*Predicate{p.A, p.B}, false
+			} else if c == TFalse {
+				used, all = []*Predicate{p.A, p.C}, false
+			} else {
+				// both branches have the same value
+				used, all = []*Predicate{p.B, p.C}, false
+			}
+		case AtMostOp, AtLeastOp, ExactlyOp:
+			used, all = cardReasons(p, v, ev)
+		}
+	}
+	decisive := make(map[*Predicate]bool)
+	for _, c := range used {
+		decisive[c] = true
+	}
+	for _, c := range cs {
+		if all || decisive[c] {
+			x.mark(c, env)
+		} else {
+			x.skip(c)
+		}
+	}
+}
+
+// skip marks p and its subtrees as skipped
+func (x *Explanation) skip(p *Predicate) {
+	x.Marks[p] = Skipped
+	for _, c := range children(p) {
+		x.skip(c)
+	}
+}
+
+// cardReasons returns the first operands of the cardinality
+// constraint p that are enough for it to have the value v, or
+// all when it depends on every operand
+func cardReasons(p *Predicate, v Truth,
+	ev func(*Predicate) Truth) (r []*Predicate, all bool) {
+	// n is the amount of true operands
+	n := 0
+	for _, a := range p.Args {
+		if ev(a) == TTrue {
+			n = n + 1
+		}
+	}
+	// k true operands make atleast(k) true, and k + 1 make
+	// atmost(k) false, while enough false operands do the
+	// opposite
+	want, k := TTrue, 0
+	if p.Operator == AtLeastOp && v == TTrue {
+		k = p.K
+	} else if v == TFalse && (p.Operator == AtMostOp || n > p.K) {
+		k = p.K + 1
+	} else if p.Operator == AtMostOp {
+		want, k = TFalse, len(p.Args)-p.K
+	} else if v == TFalse {
+		want, k = TFalse, len(p.Args)-p.K+1
+	} else {
+		// exactly is true
+		all = true
+	}
+	r = []*Predicate{}
+	for i := 0; !all && len(r) < k && i != len(p.Args); i++ {
+		if ev(p.Args[i]) == want {
+			r = append(r, p.Args[i])
+		}
+	}
+	return
+}
+
+// leafNames adds to names the variables of p when it's a term,
+// an atom or a comparison
+func leafNames(p *Predicate, names map[string]bool) {
+	if p.Operator == Term && p.String != TrueStr &&
+		p.String != FalseStr {
+		names[p.String] = true
+	} else if p.Operator == AtomOp {
+		names[String(p)] = true
+	} else if isComparison(p.Operator) {
+		vs := append([]*Predicate{p.A}, p.Args...)
+		if p.B != nil {
+			vs = append(vs, p.B)
+		}
+		for _, v := range vs {
+			if v.Operator == VarOp {
+				names[v.String] = true
+			}
+		}
+	}
+}
+
+// reasons returns the values of names in env as literals and
+// equalities, using the atoms in p for the atom names
+func reasons(p *Predicate, env Env, names []string) (r []*Predicate) {
+	atoms := make(map[string]*Predicate)
+	var visit func(*Predicate)
+	visit = func(q *Predicate) {
+		if q.Operator == AtomOp {
+			atoms[String(q)] = q
+		}
+		for _, c := range children(q) {
+			visit(c)
+		}
+	}
+	visit(p)
+	r = make([]*Predicate, 0, len(names))
+	for _, n := range names {
+		var l *Predicate
+		if v, ok := env.Bool(n); ok {
+			l = NewTerm(n)
+			if a, isAtom := atoms[n]; isAtom {
+				l = copyAtom(a)
+			}
+			if !v {
+				l = &Predicate{Operator: NotOp, B: l}
+			}
+		} else {
+			v, _ := env.Value(n)
+			l = &Predicate{Operator: EqOp,
+				A: &Predicate{Operator: VarOp, String: n}, B: literal(v)}
+		}
+		r = append(r, l)
+	}
+	return
+}
+
+// restricted is an Env that only defines the names in an
+// underlying Env, and the constants true and false
+type restricted struct {
+	env   Env
+	names map[string]bool
+}
+
+func restrict(env Env, names []string) (r *restricted) {
+	r = &restricted{env: env, names: make(map[string]bool)}
+	for _, n := range names {
+		r.names[n] = true
+	}
+	return
+}
+
+func (r *restricted) Bool(name string) (v, ok bool) {
+	if r.names[name] || name == TrueStr || name == FalseStr {
+		v, ok = r.env.Bool(name)
+	}
+	return
+}
+
+func (r *restricted) Value(name string) (v any, ok bool) {
+	if r.names[name] {
+		v, ok = r.env.Value(name)
+	}
+	return
+}
+
+// Highlight returns the string of the explained predicate, as
+// returned by String, where f replaces the strings of the
+// decisive terms, atoms and comparisons, and of the outermost
+// skipped subtrees
+func (x *Explanation) Highlight(f func(s string, m Mark) string) (r string) {
+	// outer has the subtrees that f replaces
+	outer := make(map[*Predicate]bool)
+	var visit func(*Predicate, bool)
+	visit = func(q *Predicate, skipped bool) {
+		m, ok := x.Marks[q]
+		leaf := q.Operator == Term || q.Operator == AtomOp ||
+			isComparison(q.Operator)
+		outer[q] = ok && ((m == Skipped && !skipped) ||
+			(m == Decisive && leaf))
+		for _, c := range children(q) {
+			visit(c, skipped || m == Skipped)
+		}
+	}
+	visit(x.Predicate, false)
+	r = str(x.Predicate, func(q *Predicate, s string) (t string) {
+		t = s
+		if outer[q] {
+			t = f(s, x.Marks[q])
+		}
+		return
+	})
+	return
+}
+
+// String returns the explained predicate with its skipped
+// subtrees between brackets
+func (x *Explanation) String() (r string) {
+	r = x.Highlight(func(s string, m Mark) (t string) {
+		t = s
+		if m == Skipped {
+			t = "[" + s + "]"
+		}
+		return
+	})
+	return
+}
@@ -0,0 +1,117 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	env := MapEnv{"A": true, "B": false, "C": true, "D": false,
+		"age": 21, "tag": "b"}
+	ps := []struct {
+		pred, value, reasons, marked string
+	}{
+		{"A ∨ B", "true", "A", "*A* ∨ [B]"},
+		{"B ∨ A", "true", "A", "[B] ∨ *A*"},
+		{"A ∧ ¬B", "true", "A, ¬B", "*A* ∧ ¬*B*"},
+		{"(A ∨ C) ∧ (B ∨ C)", "true", "C", "([A] ∨ *C*) ∧ ([B] ∨ *C*)"},
+		{"B ∧ (A ∨ C)", "false", "¬B", "*B* ∧ ([A ∨ C])"},
+		{"A ⇒ B ∧ D", "false", "A, ¬B", "*A* ⇒ *B* ∧ [D]"},
+		{"B ⇒ D", "true", "¬B", "*B* ⇒ [D]"},
+		{"A ⇐ D", "true", "A", "*A* ⇐ [D]"},
+		{"A ≡ C", "true", "A, C", "*A* ≡ *C*"},
+		{"if B then D else C", "true", "¬B, C",
+			"if *B* then [D] else *C*"},
+		{"atleast(2; A, B, C, D)", "true", "A, C",
+			"atleast(2; *A*, [B], *C*, [D])"},
+		{"atmost(1; A, B, C, D)", "false", "A, C",
+			"atmost(1; *A*, [B], *C*, [D])"},
+		{"atmost(2; A, B, C, D)", "true", "¬B, ¬D",
+			"atmost(2; [A], *B*, [C], *D*)"},
+		{"exactly(1; A, B, C)", "false", "A, C",
+			"exactly(1; *A*, [B], *C*)"},
+		{"atmost(2; A, B)", "true", "", "atmost(2; [A], [B])"},
+		{"let x := A ∧ B in x ∨ C", "true", "C", "([A ∧ B]) ∨ *C*"},
+		{"∃ x ∈ {A, B} : ¬x", "true", "¬B",
+			"[¬A] ∨ ¬*B*"},
+		{`age ≥ 18 ∧ (tag ∈ {"a"} ∨ D)`, "false", "¬D, tag = \"b\"",
+			`[age ≥ 18] ∧ (*tag ∈ {"a"}* ∨ *D*)`},
+		{"A ∧ E", "unknown", "", "*A* ∧ *E*"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		x := Explain(p, env)
+		require.Equal(t, ps[i].value, x.Value.String(), "At %d", i)
+		require.Equal(t, ps[i].reasons, joinArgs(x.Reasons, String),
+			"At %d", i)
+		marked := x.Highlight(func(s string, m Mark) (r string) {
+			r = "[" + s + "]"
+			if m == Decisive {
+				r = "*" + s + "*"
+			}
+			return
+		})
+		require.Equal(t, ps[i].marked, marked, "At %d", i)
+		if x.Value != TUnknown {
+			// the reasons are sufficient and minimal
+			m := MapEnv{}
+			for _, r := range x.Reasons {
+				addReason(m, r, env)
+			}
+			require.Equal(t, x.Value, Eval(p, m, Kleene), "At %d", i)
+			for k := range m {
+				n := MapEnv{}
+				for j, v := range m {
+					if j != k {
+						n[j] = v
+					}
+				}
+				require.Equal(t, TUnknown, Eval(p, n, Kleene), "At %d", i)
+			}
+		}
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func addReason(m MapEnv, r *Predicate, env MapEnv) {
+	if r.Operator == NotOp {
+		r = r.B
+	}
+	name := String(r)
+	if r.Operator == EqOp {
+		name = r.A.String
+	}
+	m[name] = env[name]
+}
+
+func TestExplanationString(t *testing.T) {
+	p, e := Parse(strings.NewReader("(A ∨ B) ∧ C ∧ D"))
+	require.NoError(t, e)
+	x := Explain(p, MapEnv{"A": true, "C": false, "D": true})
+	require.Equal(t, TFalse, x.Value)
+	require.Equal(t, "([A ∨ B]) ∧ C ∧ [D]", x.String())
+	require.Equal(t, "¬C", joinArgs(x.Reasons, String))
+}
@@ -5,4 +5,9 @@ require (
 	github.com/stretchr/testify v1.3.0
 )
 
-go 1.13
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+go 1.18
@@ -0,0 +1,129 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDot writes p as a Graphviz DOT digraph. When shared is
+// true, structurally equal subtrees are drawn as a single node,
+// showing p as a DAG
+func WriteDot(w io.Writer, p *Predicate, shared bool) (e error) {
+	g := newGraph(p, shared)
+	var sb strings.Builder
+	sb.WriteString("digraph predicate {\n")
+	for i, l := range g.labels {
+		fmt.Fprintf(&sb, "\tn%d [label=%s];\n", i, dotQuote(l))
+	}
+	for _, d := range g.edges {
+		if d[2] == 0 {
+			fmt.Fprintf(&sb, "\tn%d -> n%d;\n", d[0], d[1])
+		} else {
+			fmt.Fprintf(&sb, "\tn%d -> n%d [label=\"%d\"];\n", d[0], d[1],
+				d[2])
+		}
+	}
+	sb.WriteString("}\n")
+	_, e = io.WriteString(w, sb.String())
+	return
+}
+
+// WriteMermaid writes p as a Mermaid flowchart, sharing
+// subtrees like WriteDot
+func WriteMermaid(w io.Writer, p *Predicate, shared bool) (e error) {
+	g := newGraph(p, shared)
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	for i, l := range g.labels {
+		l = strings.ReplaceAll(l, `"`, "#quot;")
+		fmt.Fprintf(&sb, "\tn%d[\"%s\"]\n", i, l)
+	}
+	for _, d := range g.edges {
+		if d[2] == 0 {
+			fmt.Fprintf(&sb, "\tn%d --> n%d\n", d[0], d[1])
+		} else {
+			fmt.Fprintf(&sb, "\tn%d -->|%d| n%d\n", d[0], d[2], d[1])
+		}
+	}
+	_, e = io.WriteString(w, sb.String())
+	return
+}
+
+func dotQuote(s string) (r string) {
+	r = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) +
+		`"`
+	return
+}
+
+type graph struct {
+	labels []string
+	// edges go from a node to its operand, with the position of
+	// the operand from 1, or 0 when it's the only one, which
+	// keeps the order of non-commutative operators and the
+	// repeated operands in the shared DAG
+	edges [][3]int
+}
+
+func newGraph(p *Predicate, shared bool) (g *graph) {
+	g = new(graph)
+	ids := make(map[string]int)
+	var add func(*Predicate) int
+	add = func(q *Predicate) (n int) {
+		var key string
+		if shared {
+			key = String(q)
+		}
+		n, ok := ids[key]
+		if !ok || !shared {
+			n = len(g.labels)
+			ids[key] = n
+			if q.Operator == Term {
+				g.labels = append(g.labels, q.String)
+			} else if isCard(q.Operator) {
+				g.labels = append(g.labels, fmt.Sprintf("%s %d", q.Operator,
+					q.K))
+			} else if q.Operator == AtomOp || isComparison(q.Operator) {
+				g.labels = append(g.labels, String(q))
+			} else if isQuant(q.Operator) {
+				g.labels = append(g.labels, q.Operator+" "+q.String+" "+InOp+
+					" "+domainString(q))
+			} else if q.Operator == LetOp {
+				g.labels = append(g.labels, LetOp+" "+q.String+" "+AssignOp)
+			} else {
+				g.labels = append(g.labels, q.Operator)
+			}
+			cs := children(q)
+			for i, c := range cs {
+				pos := i + 1
+				if len(cs) == 1 {
+					pos = 0
+				}
+				g.edges = append(g.edges, [3]int{n, add(c), pos})
+			}
+		}
+		return
+	}
+	add(p)
+	return
+}
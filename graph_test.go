@@ -0,0 +1,77 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	p, e := Parse(strings.NewReader("(A ∨ B) ∧ ¬(A ∨ B)"))
+	require.NoError(t, e)
+	var tree, dag strings.Builder
+	require.NoError(t, WriteDot(&tree, p, false))
+	require.NoError(t, WriteDot(&dag, p, true))
+	require.Equal(t, "digraph predicate {\n"+
+		"\tn0 [label=\"∧\"];\n"+
+		"\tn1 [label=\"∨\"];\n"+
+		"\tn2 [label=\"A\"];\n"+
+		"\tn3 [label=\"B\"];\n"+
+		"\tn4 [label=\"¬\"];\n"+
+		"\tn5 [label=\"∨\"];\n"+
+		"\tn6 [label=\"A\"];\n"+
+		"\tn7 [label=\"B\"];\n"+
+		"\tn1 -> n2 [label=\"1\"];\n"+
+		"\tn1 -> n3 [label=\"2\"];\n"+
+		"\tn0 -> n1 [label=\"1\"];\n"+
+		"\tn5 -> n6 [label=\"1\"];\n"+
+		"\tn5 -> n7 [label=\"2\"];\n"+
+		"\tn4 -> n5;\n"+
+		"\tn0 -> n4 [label=\"2\"];\n"+
+		"}\n", tree.String())
+	require.Equal(t, "digraph predicate {\n"+
+		"\tn0 [label=\"∧\"];\n"+
+		"\tn1 [label=\"∨\"];\n"+
+		"\tn2 [label=\"A\"];\n"+
+		"\tn3 [label=\"B\"];\n"+
+		"\tn4 [label=\"¬\"];\n"+
+		"\tn1 -> n2 [label=\"1\"];\n"+
+		"\tn1 -> n3 [label=\"2\"];\n"+
+		"\tn0 -> n1 [label=\"1\"];\n"+
+		"\tn4 -> n1;\n"+
+		"\tn0 -> n4 [label=\"2\"];\n"+
+		"}\n", dag.String())
+	require.Equal(t, `"a\"b\\"`, dotQuote(`a"b\`))
+}
+
+func TestWriteMermaid(t *testing.T) {
+	p, e := Parse(strings.NewReader("A ⇒ A"))
+	require.NoError(t, e)
+	var sb strings.Builder
+	require.NoError(t, WriteMermaid(&sb, p, true))
+	require.Equal(t, "flowchart TD\n"+
+		"\tn0[\"⇒\"]\n"+
+		"\tn1[\"A\"]\n"+
+		"\tn0 -->|1| n1\n"+
+		"\tn0 -->|2| n1\n", sb.String())
+}
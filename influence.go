@@ -0,0 +1,83 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+// EssentialVars returns the variables of p its value depends
+// on, in alphabetical order, which are the ones tested by its
+// BDD. p must be propositional, as Compile requires
+func EssentialVars(p *Predicate) (vs []string, e error) {
+	b, e := Compile(p)
+	if e == nil {
+		tested := make(map[int]bool)
+		seen := make(map[int]bool)
+		var visit func(int)
+		visit = func(x int) {
+			if x > 1 && !seen[x] {
+				seen[x] = true
+				tested[b.nodes[x].level] = true
+				visit(b.nodes[x].lo)
+				visit(b.nodes[x].hi)
+			}
+		}
+		visit(b.root)
+		vs = []string{}
+		for i, v := range b.Vars {
+			if tested[i] {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return
+}
+
+// Influence returns, for each variable of p, the fraction of
+// the assignments where flipping that variable flips the value
+// of p, which is zero for the variables that aren't essential.
+// It's the probability of p[x := false] ≢ p[x := true] with
+// every variable true with probability 1/2. p must be
+// propositional, as Compile requires
+func Influence(p *Predicate) (m map[string]float64, e error) {
+	b, e := Compile(p)
+	if e == nil {
+		m = make(map[string]float64)
+		for _, v := range b.Vars {
+			lo := b.restrict(b.root, v, false)
+			hi := b.restrict(b.root, v, true)
+			m[v] = b.probability(b.ite(lo, b.not(hi), hi), nil)
+		}
+	}
+	return
+}
+
+// Symmetric returns whether swapping the values of the
+// variables x and y leaves the value of p unchanged, which
+// happens when p[x, y := false, true] ≡ p[x, y := true, false].
+// p must be propositional, as Compile requires
+func Symmetric(p *Predicate, x, y string) (ok bool, e error) {
+	b, e := Compile(p)
+	if e == nil {
+		xy := b.restrict(b.restrict(b.root, x, false), y, true)
+		yx := b.restrict(b.restrict(b.root, x, true), y, false)
+		// equal functions have the same node
+		ok = x == y || xy == yx
+	}
+	return
+}
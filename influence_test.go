@@ -0,0 +1,108 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestInfluence(t *testing.T) {
+	ps := []struct {
+		pred      string
+		essential []string
+		influence map[string]float64
+	}{
+		{"A", []string{"A"}, map[string]float64{"A": 1}},
+		{"A ∧ B", []string{"A", "B"},
+			map[string]float64{"A": 0.5, "B": 0.5}},
+		{"A ∨ (A ∧ B)", []string{"A"},
+			map[string]float64{"A": 1, "B": 0}},
+		{"(A ∧ B) ∨ (¬A ∧ B)", []string{"B"},
+			map[string]float64{"A": 0, "B": 1}},
+		{"A ≢ B ≢ C", []string{"A", "B", "C"},
+			map[string]float64{"A": 1, "B": 1, "C": 1}},
+		{"atleast(2; A, B, C)", []string{"A", "B", "C"},
+			map[string]float64{"A": 0.5, "B": 0.5, "C": 0.5}},
+		{"if S then A else B", []string{"A", "B", "S"},
+			map[string]float64{"A": 0.5, "B": 0.5, "S": 0.5}},
+		{"C ∨ ¬C", []string{}, map[string]float64{"C": 0}},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		vs, e := EssentialVars(p)
+		require.NoError(t, e)
+		require.Equal(t, ps[i].essential, vs, "At %d", i)
+		m, e := Influence(p)
+		require.NoError(t, e)
+		require.Equal(t, ps[i].influence, m, "At %d", i)
+		// the influence is the fraction of assignments where
+		// the cofactors differ
+		x := Vars(p)
+		for _, v := range x {
+			n, total := 0, 0
+			forallAssign(x, func(a map[string]bool) {
+				a[v] = false
+				lo := Eval(p, MapEnv(toAny(a)), Kleene)
+				a[v] = true
+				if lo != Eval(p, MapEnv(toAny(a)), Kleene) {
+					n = n + 1
+				}
+				total = total + 1
+			})
+			require.Equal(t, float64(n)/float64(total), m[v], "At %d", i)
+		}
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestSymmetric(t *testing.T) {
+	ps := []struct {
+		pred string
+		x, y string
+		ok   bool
+	}{
+		{"A ∧ B", "A", "B", true},
+		{"A ⇒ B", "A", "B", false},
+		{"(A ∨ B) ∧ C", "A", "B", true},
+		{"(A ∨ B) ∧ C", "A", "C", false},
+		{"exactly(1; A, B, C)", "B", "C", true},
+		{"A ∧ ¬B", "A", "A", true},
+		{"A", "A", "D", false},
+		{"A", "D", "E", true},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		ok, e := Symmetric(p, ps[i].x, ps[i].y)
+		require.NoError(t, e)
+		require.Equal(t, ps[i].ok, ok, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	p, e := Parse(strings.NewReader("n < 1"))
+	require.NoError(t, e)
+	_, e = Influence(p)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+}
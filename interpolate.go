@@ -0,0 +1,98 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SatisfiableErr is returned by Interpolate when the predicates
+// can be true at the same time
+type SatisfiableErr struct {
+	// Model is an assignment that satisfies both predicates
+	Model map[string]bool
+}
+
+func (s *SatisfiableErr) Error() (r string) {
+	r = fmt.Sprintf("Satisfiable with %s", literals(s.Model))
+	return
+}
+
+// literals returns the assignment m as literals sorted by name,
+// separated by commas
+func literals(m map[string]bool) (r string) {
+	ns := make([]string, 0, len(m))
+	for k := range m {
+		ns = append(ns, k)
+	}
+	sort.Strings(ns)
+	ls := make([]string, len(ns))
+	for i, n := range ns {
+		ls[i] = n
+		if !m[n] {
+			ls[i] = NotOp + n
+		}
+	}
+	r = strings.Join(ls, Comma+" ")
+	return
+}
+
+// Interpolate returns a Craig interpolant of a and b when a ∧ b
+// is unsatisfiable, which is a predicate I over the variables
+// shared by a and b where a ⇒ I and I ∧ b is unsatisfiable.
+// It's the strongest one, a with the variables not in b
+// quantified existentially. Both must be propositional, like
+// the predicates of a Solver
+func Interpolate(a, b *Predicate) (r *Predicate, e error) {
+	s := NewSolver()
+	e = s.Add(a)
+	if e == nil {
+		e = s.Add(b)
+	}
+	var ok bool
+	if e == nil {
+		ok, e = s.Check()
+	}
+	if e == nil && ok {
+		e = &SatisfiableErr{Model: s.Model()}
+	}
+	var x, y *Predicate
+	if e == nil {
+		x, e = propositional(a)
+	}
+	if e == nil {
+		y, e = propositional(b)
+	}
+	if e == nil {
+		shared := varNodes(y)
+		var local []string
+		for v := range varNodes(x) {
+			if _, ok := shared[v]; !ok {
+				local = append(local, v)
+			}
+		}
+		sort.Strings(local)
+		r = Exists(x, local...)
+	}
+	return
+}
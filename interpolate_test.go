@@ -0,0 +1,83 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"errors"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	ps := []struct {
+		a, b, itp string
+	}{
+		{"A ∧ (A ⇒ B)", "¬B", "B"},
+		{"p ∧ (p ⇒ q) ∧ (q ⇒ r)", "¬r ∧ s", "r"},
+		{"(x ∨ y) ∧ ¬x", "¬y ∧ z", "y"},
+		{"A ∧ ¬A", "B", "false"},
+		{"C", "D ∧ ¬D", "true"},
+		{"exactly(1; a, b) ∧ c", "a ≡ b", "if a then ¬b else b"},
+		{"∀ x ∈ {m, n} : p(x)", "¬p(n)", "p(n)"},
+	}
+	inf := func(i int) {
+		a, e := Parse(strings.NewReader(ps[i].a))
+		require.NoError(t, e)
+		b, e := Parse(strings.NewReader(ps[i].b))
+		require.NoError(t, e)
+		r, e := Interpolate(a, b)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].itp, String(r), "At %d", i)
+		// a ⇒ I and I ∧ b is unsatisfiable
+		s := NewSolver()
+		require.NoError(t, s.Add(a))
+		require.NoError(t, s.Add(negate(r)))
+		ok, e := s.Check()
+		require.NoError(t, e)
+		require.False(t, ok, "At %d", i)
+		s = NewSolver()
+		require.NoError(t, s.Add(b))
+		require.NoError(t, s.Add(r))
+		ok, e = s.Check()
+		require.NoError(t, e)
+		require.False(t, ok, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestInterpolateErr(t *testing.T) {
+	a, e := Parse(strings.NewReader("A ∨ B"))
+	require.NoError(t, e)
+	b, e := Parse(strings.NewReader("¬A"))
+	require.NoError(t, e)
+	_, e = Interpolate(a, b)
+	var s *SatisfiableErr
+	require.True(t, errors.As(e, &s))
+	require.Equal(t, map[string]bool{"A": false, "B": true}, s.Model)
+	require.Equal(t, "Satisfiable with ¬A, B", e.Error())
+
+	c, e := Parse(strings.NewReader("n < 1"))
+	require.NoError(t, e)
+	_, e = Interpolate(a, c)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+}
@@ -0,0 +1,206 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+// Truth is a value of three-valued logic, where unknown is
+// between false and true
+type Truth int
+
+const (
+	TFalse   Truth = -1
+	TUnknown Truth = 0
+	TTrue    Truth = 1
+)
+
+// Logic selects the meaning of ⇒, ⇐, ≡, ≢ and ⊕ when some
+// operand is unknown, since ¬, ∧ and ∨ are the same in both
+type Logic int
+
+const (
+	// Kleene is the strong logic of Kleene, where a ⇒ b is
+	// ¬a ∨ b, and a ≡ b is unknown when a or b are unknown
+	Kleene Logic = iota
+	// Lukasiewicz is the logic of Łukasiewicz, where
+	// unknown ⇒ unknown and unknown ≡ unknown are true
+	Lukasiewicz
+)
+
+// Eval evaluates p in three-valued logic, where the terms,
+// atoms and comparisons without value in env are unknown, and
+// the unknown values propagate through the operators instead
+// of remaining as a predicate, like in ReduceEnv. The constants
+// true and false are known even when env doesn't define them.
+// Quantifiers over named domains are unknown, since they must
+// be grounded before evaluating them
+func Eval(p *Predicate, env Env, l Logic) (t Truth) {
+	ev := func(q *Predicate) Truth { return Eval(q, env, l) }
+	switch p.Operator {
+	case Term:
+		t = known(env.Bool(p.String))
+		if t == TUnknown && (p.String == TrueStr || p.String == FalseStr) {
+			t = known(p.String == TrueStr, true)
+		}
+	case AtomOp:
+		// an atom is a variable named by its string
+		t = known(env.Bool(String(p)))
+	case NotOp:
+		t = -ev(p.B)
+	case AndOp:
+		t = minTruth(ev(p.A), ev(p.B))
+	case OrOp:
+		t = maxTruth(ev(p.A), ev(p.B))
+	case NandOp:
+		t = -minTruth(ev(p.A), ev(p.B))
+	case NorOp:
+		t = -maxTruth(ev(p.A), ev(p.B))
+	case ImpliesOp:
+		t = l.implies(ev(p.A), ev(p.B))
+	case FollowsOp:
+		t = l.implies(ev(p.B), ev(p.A))
+	case EquivalesOp:
+		t = l.equiv(ev(p.A), ev(p.B))
+	case NotEquivalesOp, XorOp:
+		t = -l.equiv(ev(p.A), ev(p.B))
+	case IfOp:
+		c, a, b := ev(p.A), ev(p.B), ev(p.C)
+		if c == TTrue || (c == TUnknown && a == b) {
+			t = a
+		} else if c == TFalse {
+			t = b
+		}
+	case LetOp:
+		x, _ := Expand(p, nil)
+		t = ev(x)
+	case ForallOp, ExistsOp:
+		x, e := Ground(p, nil)
+		if e == nil {
+			t = ev(x)
+		}
+	case AtMostOp, AtLeastOp, ExactlyOp:
+		t = evalCard(p, ev)
+	default:
+		// comparisons are known when their variables are
+		r := ReduceEnv(p, env)
+		if r.Operator == Term {
+			t = known(r.String == TrueStr,
+				r.String == TrueStr || r.String == FalseStr)
+		}
+	}
+	return
+}
+
+// evalCard evaluates the cardinality constraint p, which is
+// unknown when its unknown operands can make it true or false
+func evalCard(p *Predicate, ev func(*Predicate) Truth) (t Truth) {
+	// n is the amount of true operands and u of unknown ones
+	n, u := 0, 0
+	for _, a := range p.Args {
+		v := ev(a)
+		if v == TTrue {
+			n = n + 1
+		} else if v == TUnknown {
+			u = u + 1
+		}
+	}
+	atMost, atLeast := TTrue, TTrue
+	if n > p.K {
+		atMost = TFalse
+	} else if n+u > p.K {
+		atMost = TUnknown
+	}
+	if n+u < p.K {
+		atLeast = TFalse
+	} else if n < p.K {
+		atLeast = TUnknown
+	}
+	if p.Operator == AtMostOp {
+		t = atMost
+	} else if p.Operator == AtLeastOp {
+		t = atLeast
+	} else {
+		t = minTruth(atMost, atLeast)
+	}
+	return
+}
+
+func (l Logic) implies(a, b Truth) (t Truth) {
+	if l == Lukasiewicz {
+		// min(1, 1 - a + b) with the values in [0, 1]
+		t = minTruth(TTrue, TTrue-a+b)
+	} else {
+		t = maxTruth(-a, b)
+	}
+	return
+}
+
+func (l Logic) equiv(a, b Truth) (t Truth) {
+	if l == Lukasiewicz {
+		// 1 - |a - b| with the values in [0, 1]
+		t = TTrue - maxTruth(a-b, b-a)
+	} else {
+		// the product is unknown when a factor is
+		t = a * b
+	}
+	return
+}
+
+// known returns the truth of v when ok, otherwise unknown
+func known(v, ok bool) (t Truth) {
+	if ok && v {
+		t = TTrue
+	} else if ok {
+		t = TFalse
+	}
+	return
+}
+
+// Bool returns the boolean value of t, and whether it's known
+func (t Truth) Bool() (v, ok bool) {
+	v, ok = t == TTrue, t != TUnknown
+	return
+}
+
+func (t Truth) String() (s string) {
+	if t == TTrue {
+		s = TrueStr
+	} else if t == TFalse {
+		s = FalseStr
+	} else {
+		s = "unknown"
+	}
+	return
+}
+
+func minTruth(a, b Truth) (t Truth) {
+	t = a
+	if b < a {
+		t = b
+	}
+	return
+}
+
+func maxTruth(a, b Truth) (t Truth) {
+	t = a
+	if b > a {
+		t = b
+	}
+	return
+}
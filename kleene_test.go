@@ -0,0 +1,96 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := MapEnv{"t": true, "f": false, "age": 20, "p(a)": true}
+	// u and v are unknown
+	ps := []struct {
+		pred        string
+		kleene, luk Truth
+	}{
+		{"t", TTrue, TTrue},
+		{"u", TUnknown, TUnknown},
+		{"¬u", TUnknown, TUnknown},
+		{"u ∧ f", TFalse, TFalse},
+		{"u ∧ t", TUnknown, TUnknown},
+		{"u ∨ t", TTrue, TTrue},
+		{"u ⇒ u", TUnknown, TTrue},
+		{"u ⇒ v", TUnknown, TTrue},
+		{"t ⇒ u", TUnknown, TUnknown},
+		{"u ⇒ f", TUnknown, TUnknown},
+		{"f ⇒ u", TTrue, TTrue},
+		{"u ⇐ u", TUnknown, TTrue},
+		{"u ≡ u", TUnknown, TTrue},
+		{"u ≡ t", TUnknown, TUnknown},
+		{"t ≡ f", TFalse, TFalse},
+		{"u ≢ u", TUnknown, TFalse},
+		{"u ⊕ f", TUnknown, TUnknown},
+		{"u ↑ f", TTrue, TTrue},
+		{"u ↓ t", TFalse, TFalse},
+		{"if u then t else t", TTrue, TTrue},
+		{"if u then t else f", TUnknown, TUnknown},
+		{"if f then u else t", TTrue, TTrue},
+		{"let x := u ∨ t in x ∧ t", TTrue, TTrue},
+		{"atmost(1; t, u, f)", TUnknown, TUnknown},
+		{"atmost(1; t, t, u)", TFalse, TFalse},
+		{"atleast(1; t, u)", TTrue, TTrue},
+		{"exactly(2; t, u, v)", TUnknown, TUnknown},
+		{"exactly(1; f, u)", TUnknown, TUnknown},
+		{"exactly(2; t, f)", TFalse, TFalse},
+		{"age ≥ 18 ∧ t", TTrue, TTrue},
+		{"height > 2 ∨ f", TUnknown, TUnknown},
+		{"∀ x ∈ {a, b} : p(x)", TUnknown, TUnknown},
+		{"∃ x ∈ {a, b} : p(x)", TTrue, TTrue},
+		{"∀ x ∈ D : p(x)", TUnknown, TUnknown},
+		{"true ∧ ¬false", TTrue, TTrue},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].kleene, Eval(p, env, Kleene), "At %d", i)
+		require.Equal(t, ps[i].luk, Eval(p, env, Lukasiewicz), "At %d", i)
+		if v, ok := ps[i].kleene.Bool(); ok {
+			// known values agree with the reduction
+			require.Equal(t, v, String(ReduceEnv(p, env)) == TrueStr,
+				"At %d", i)
+		}
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestTruth(t *testing.T) {
+	require.Equal(t, "true", TTrue.String())
+	require.Equal(t, "false", TFalse.String())
+	require.Equal(t, "unknown", TUnknown.String())
+	v, ok := TFalse.Bool()
+	require.True(t, !v && ok)
+	_, ok = TUnknown.Bool()
+	require.False(t, ok)
+	require.Equal(t, TUnknown, known(true, false))
+}
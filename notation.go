@@ -0,0 +1,255 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// notation defines how render writes the parts of a predicate,
+// while the placement of parenthesis is the one of String
+type notation struct {
+	term  func(string) string
+	op    func(string) string
+	paren func(string) string
+	node  func(*Predicate, string) string
+	// lit writes numbers and strings, when it's nil they're
+	// written like terms
+	lit func(*Predicate) string
+	// sep separates binary operators from their operands
+	sep string
+}
+
+func render(p *Predicate, n *notation) (r string) {
+	child := func(q *Predicate, par bool) (s string) {
+		s = render(q, n)
+		if par {
+			s = n.paren(s)
+		}
+		return
+	}
+	if p.Operator == Term {
+		r = n.term(p.String)
+	} else if p.Operator == NotOp {
+		r = n.op(NotOp) + child(p.B, !atomic(p.B.Operator))
+	} else if p.Operator == LetOp {
+		r = n.op(LetOp) + n.sep + n.term(p.String) + n.sep +
+			n.op(AssignOp) + n.sep + render(p.A, n) + n.sep + n.op(InKw) +
+			n.sep + render(p.B, n)
+	} else if isCard(p.Operator) {
+		r = n.op(p.Operator) + n.paren(render(NewNumber(float64(p.K)), n)+
+			n.op(Semicolon)+n.sep+n.list(p.Args))
+	} else if p.Operator == VarOp ||
+		(isValue(p.Operator) && n.lit == nil) {
+		r = n.term(valueString(p))
+	} else if isValue(p.Operator) {
+		r = n.lit(p)
+	} else if p.Operator == InOp {
+		r = render(p.A, n) + n.sep + n.op(InOp) + n.sep + n.op(OBrace) +
+			n.list(p.Args) + n.op(CBrace)
+	} else if p.Operator == AtomOp {
+		r = n.term(p.String) + n.paren(n.list(p.Args))
+	} else if isQuant(p.Operator) {
+		var dom string
+		if p.Domain != "" {
+			dom = n.term(p.Domain)
+		} else {
+			dom = n.op(OBrace) + n.list(p.Args) + n.op(CBrace)
+		}
+		r = n.op(p.Operator) + n.sep + n.term(p.String) + n.sep +
+			n.op(InOp) + n.sep + dom + n.sep + n.op(Colon) + n.sep +
+			render(p.B, n)
+	} else if p.Operator == IfOp {
+		r = n.op(IfOp) + n.sep + render(p.A, n) + n.sep + n.op(ThenKw) +
+			n.sep + render(p.B, n) + n.sep + n.op(ElseKw) + n.sep +
+			render(p.C, n)
+	} else {
+		r = child(p.A, leftFormat(p.Operator, p.A.Operator) != "%s") +
+			n.sep + n.op(p.Operator) + n.sep +
+			child(p.B, format(p.Operator, p.B.Operator) != "%s")
+	}
+	if n.node != nil {
+		r = n.node(p, r)
+	}
+	return
+}
+
+// list renders ps separated by commas
+func (n *notation) list(ps []*Predicate) (r string) {
+	ss := make([]string, len(ps))
+	for i, q := range ps {
+		ss[i] = render(q, n)
+	}
+	r = strings.Join(ss, n.op(Comma)+n.sep)
+	return
+}
+
+// LaTeX returns p as a LaTeX math mode formula
+func LaTeX(p *Predicate) (r string) {
+	ops := map[string]string{
+		NotOp:          `\neg `,
+		AndOp:          `\wedge`,
+		OrOp:           `\vee`,
+		EquivalesOp:    `\equiv`,
+		NotEquivalesOp: `\not\equiv`,
+		ImpliesOp:      `\Rightarrow`,
+		FollowsOp:      `\Leftarrow`,
+		XorOp:          `\oplus`,
+		NandOp:         `\uparrow`,
+		NorOp:          `\downarrow`,
+		LetOp:          `\mathbf{let}\;`,
+		AssignOp:       ":=",
+		InKw:           `\;\mathbf{in}\;`,
+		AtMostOp:       `\mathrm{atmost}`,
+		AtLeastOp:      `\mathrm{atleast}`,
+		ExactlyOp:      `\mathrm{exactly}`,
+		Semicolon:      ";",
+		Comma:          ",",
+		EqOp:           "=",
+		NeOp:           `\neq`,
+		LtOp:           "<",
+		LeOp:           `\leq`,
+		GtOp:           ">",
+		GeOp:           `\geq`,
+		ForallOp:       `\forall`,
+		ExistsOp:       `\exists`,
+		InOp:           `\in`,
+		Colon:          ":",
+		OBrace:         `\{`,
+		CBrace:         `\}`,
+		IfOp:           `\mathbf{if}\;`,
+		ThenKw:         `\;\mathbf{then}\;`,
+		ElseKw:         `\;\mathbf{else}\;`,
+	}
+	// names are in math mode, and strings in text mode
+	esc := strings.NewReplacer(`\`, `\backslash{}`, "_", `\_`,
+		"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "{", `\{`,
+		"}", `\}`, "^", `\hat{}`, "~", `\sim{}`)
+	textEsc := strings.NewReplacer(`\`, `\textbackslash{}`, "_", `\_`,
+		"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "{", `\{`,
+		"}", `\}`, "^", `\^{}`, "~", `\~{}`)
+	n := &notation{
+		term: func(s string) (t string) {
+			if utf8.RuneCountInString(s) == 1 {
+				t = esc.Replace(s)
+			} else {
+				t = `\mathit{` + esc.Replace(s) + "}"
+			}
+			return
+		},
+		op:    func(o string) string { return ops[o] },
+		paren: func(s string) string { return "(" + s + ")" },
+		lit: func(q *Predicate) (t string) {
+			t = valueString(q)
+			if q.Operator == StringOp {
+				t = `\text{` + textEsc.Replace(t) + "}"
+			}
+			return
+		},
+		sep: " ",
+	}
+	r = render(p, n)
+	return
+}
+
+// MathML returns p as a MathML math element
+func MathML(p *Predicate) (r string) {
+	n := &notation{
+		term: func(s string) string {
+			return "<mi>" + html.EscapeString(s) + "</mi>"
+		},
+		op: func(o string) string { return "<mo>" + html.EscapeString(o) + "</mo>" },
+		paren: func(s string) string {
+			return "<mo>(</mo>" + s + "<mo>)</mo>"
+		},
+		node: func(q *Predicate, s string) (t string) {
+			t = s
+			if q.Operator != Term && !isValue(q.Operator) {
+				t = "<mrow>" + s + "</mrow>"
+			}
+			return
+		},
+		lit: func(q *Predicate) (t string) {
+			if q.Operator == NumberOp {
+				t = "<mn>" + q.String + "</mn>"
+			} else {
+				t = "<ms>" + html.EscapeString(q.String) + "</ms>"
+			}
+			return
+		},
+	}
+	r = `<math xmlns="http://www.w3.org/1998/Math/MathML">` +
+		render(p, n) + "</math>"
+	return
+}
+
+// HTML returns p as HTML text where every node is a span, with
+// a class naming its operator, for highlighting subtrees with CSS
+func HTML(p *Predicate) (r string) {
+	n := &notation{
+		term: html.EscapeString,
+		op: func(o string) string {
+			return `<span class="operator">` + html.EscapeString(o) + "</span>"
+		},
+		paren: func(s string) string { return "(" + s + ")" },
+		node: func(q *Predicate, s string) string {
+			return `<span class="` + htmlClasses[q.Operator] + `">` +
+				s + "</span>"
+		},
+		sep: " ",
+	}
+	r = render(p, n)
+	return
+}
+
+var htmlClasses = map[string]string{
+	Term:           "term",
+	NotOp:          "not",
+	AndOp:          "and",
+	OrOp:           "or",
+	EquivalesOp:    "equivales",
+	NotEquivalesOp: "not-equivales",
+	ImpliesOp:      "implies",
+	FollowsOp:      "follows",
+	XorOp:          "xor",
+	NandOp:         "nand",
+	NorOp:          "nor",
+	LetOp:          "let",
+	IfOp:           "if",
+	AtMostOp:       "atmost",
+	AtLeastOp:      "atleast",
+	ExactlyOp:      "exactly",
+	AtomOp:         "atom",
+	ForallOp:       "forall",
+	ExistsOp:       "exists",
+	EqOp:           "equal",
+	NeOp:           "not-equal",
+	LtOp:           "less",
+	LeOp:           "less-equal",
+	GtOp:           "greater",
+	GeOp:           "greater-equal",
+	InOp:           "member",
+	VarOp:          "variable",
+	NumberOp:       "number",
+	StringOp:       "string",
+}
@@ -0,0 +1,129 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNotations(t *testing.T) {
+	ps := []struct {
+		pred, latex, mathml, html string
+	}{
+		{
+			pred:   "¬A",
+			latex:  `\neg A`,
+			mathml: "<mrow><mo>¬</mo><mi>A</mi></mrow>",
+			html: `<span class="not"><span class="operator">¬</span>` +
+				`<span class="term">A</span></span>`,
+		},
+		{
+			pred:  "ab ∨ ¬(B ∧ C)",
+			latex: `\mathit{ab} \vee \neg (B \wedge C)`,
+			mathml: "<mrow><mi>ab</mi><mo>∨</mo><mrow><mo>¬</mo>" +
+				"<mo>(</mo><mrow><mi>B</mi><mo>∧</mo><mi>C</mi></mrow>" +
+				"<mo>)</mo></mrow></mrow>",
+		},
+		{
+			pred:  "A ≢ B ⇒ (C ⇐ D)",
+			latex: `A \not\equiv B \Rightarrow (C \Leftarrow D)`,
+			html: `<span class="not-equivales">` +
+				`<span class="term">A</span> ` +
+				`<span class="operator">≢</span> ` +
+				`<span class="implies"><span class="term">B</span> ` +
+				`<span class="operator">⇒</span> ` +
+				`(<span class="follows"><span class="term">C</span> ` +
+				`<span class="operator">⇐</span> ` +
+				`<span class="term">D</span></span>)</span></span>`,
+		},
+		{
+			pred:  "exactly(1; A, B)",
+			latex: `\mathrm{exactly}(1; A, B)`,
+			mathml: "<mrow><mo>exactly</mo><mo>(</mo><mn>1</mn><mo>;</mo>" +
+				"<mi>A</mi><mo>,</mo><mi>B</mi><mo>)</mo></mrow>",
+		},
+		{
+			pred:  "∃ x ∈ {a, b} : ¬p(x)",
+			latex: `\exists x \in \{a, b\} : \neg p(x)`,
+		},
+		{
+			pred:  `age ≥ 18 ∧ c ∈ {"CU"}`,
+			latex: `\mathit{age} \geq 18 \wedge c \in \{\text{"CU"}\}`,
+			mathml: "<mrow><mrow><mi>age</mi><mo>≥</mo><mn>18</mn></mrow>" +
+				"<mo>∧</mo><mrow><mi>c</mi><mo>∈</mo><mo>{</mo>" +
+				"<ms>CU</ms><mo>}</mo></mrow></mrow>",
+			html: `<span class="and"><span class="greater-equal">` +
+				`<span class="variable">age</span> ` +
+				`<span class="operator">≥</span> ` +
+				`<span class="number">18</span></span> ` +
+				`<span class="operator">∧</span> ` +
+				`<span class="member"><span class="variable">c</span> ` +
+				`<span class="operator">∈</span> ` +
+				`<span class="operator">{</span>` +
+				`<span class="string">&#34;CU&#34;</span>` +
+				`<span class="operator">}</span></span></span>`,
+		},
+		{
+			pred:  "n < 3 ∨ n > 5",
+			latex: `n < 3 \vee n > 5`,
+			mathml: "<mrow><mrow><mi>n</mi><mo>&lt;</mo><mn>3</mn></mrow>" +
+				"<mo>∨</mo><mrow><mi>n</mi><mo>&gt;</mo><mn>5</mn></mrow></mrow>",
+			html: `<span class="or"><span class="less">` +
+				`<span class="variable">n</span> ` +
+				`<span class="operator">&lt;</span> ` +
+				`<span class="number">3</span></span> ` +
+				`<span class="operator">∨</span> ` +
+				`<span class="greater"><span class="variable">n</span> ` +
+				`<span class="operator">&gt;</span> ` +
+				`<span class="number">5</span></span></span>`,
+		},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		require.Equal(t, ps[i].latex, LaTeX(p), "At %d", i)
+		if ps[i].mathml != "" {
+			require.Equal(t,
+				`<math xmlns="http://www.w3.org/1998/Math/MathML">`+
+					ps[i].mathml+"</math>", MathML(p), "At %d", i)
+		}
+		if ps[i].html != "" {
+			require.Equal(t, ps[i].html, HTML(p), "At %d", i)
+		}
+	}
+	alg.Forall(inf, len(ps))
+	p, e := Parse(strings.NewReader("if A then B ⊕ C else D ↑ E"))
+	require.NoError(t, e)
+	require.Equal(t, `\mathbf{if}\; A \;\mathbf{then}\; B \oplus C `+
+		`\;\mathbf{else}\; D \uparrow E`, LaTeX(p))
+	require.Equal(t, `\mathit{a\_b}`, LaTeX(NewTerm("a_b")))
+	require.Equal(t, `\mathit{a\backslash{}b\sim{}c\hat{}d}`,
+		LaTeX(NewTerm(`a\b~c^d`)))
+	p, e = Parse(strings.NewReader(`s = "a\\b~"`))
+	require.NoError(t, e)
+	require.Equal(t, `s = \text{"a\textbackslash{}\textbackslash{}b\~{}"}`,
+		LaTeX(p))
+	require.Equal(t, `<span class="term">a&lt;b</span>`,
+		HTML(NewTerm("a<b")))
+}
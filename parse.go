@@ -25,6 +25,8 @@ import (
 	"fmt"
 	alg "github.com/lamg/algorithms"
 	"io"
+	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -32,16 +34,243 @@ import (
 /*
 Grammar in EBNF syntax
 
-predicate = term {('≡'|'≢') term}.
+statements = [statement] {';' [statement]}.
+statement = definition | domain | predicate.
+definition = 'def' identifier ':=' predicate.
+domain = 'domain' identifier ':=' elements.
+elements = '{' [identifier {',' identifier}] '}'.
+predicate = term {('≡'|'≢'|'⊕') term}.
 term = junction ({'⇒' junction} | {'⇐' junction}).
-junction = factor ({'∨' factor} | {'∧' factor}).
-factor =	[unaryOp] (identifier | '(' predicate ')').
+junction = factor ({'∨' factor} | {'∧' factor} | ['↑' factor] |
+	['↓' factor]).
+factor =	[unaryOp] (identifier | atom | '(' predicate ')' | let | if |
+	card | quantifier | comparison).
+atom = identifier '(' identifier {',' identifier} ')'.
+comparison = operand ('='|'≠'|'<'|'≤'|'>'|'≥') operand |
+	operand '∈' '{' [literal {',' literal}] '}'.
+operand = identifier | literal.
+literal = ['-'] number | string.
+let = 'let' identifier ':=' predicate 'in' predicate.
+if = 'if' predicate 'then' predicate 'else' predicate.
+card = ('atmost' | 'atleast' | 'exactly')
+	'(' number ';' predicate {',' predicate} ')'.
+quantifier = ('∀' | '∃') identifier '∈' (identifier | elements) ':'
+	predicate.
 unaryOp = '¬'.
+
+The identifiers def, domain, let, in, if, then, else, atmost,
+atleast and exactly are keywords.
+
+The bodies of let and quantifiers, and the else branch of an
+if, extend as far as possible, so in let x := A in x ∨ C ∧ D
+the operator ∧ is an error, like it is after x ∨ C, instead of
+taking the let as its operand.
+
+An identifier is a letter or '_' followed by letters, digits,
+'_', '.' and '\'', like is_admin, user.profile and x', or any
+name between backquotes, like `first name` and `let`. The
+Syntax type restricts these forms.
+
+Numbers have an optional decimal part, like 18 and 0.5, and
+strings are enclosed by double quotes, with backslash escapes
+like the ones of Go.
+
+Spaces, including newlines, separate tokens and comments can
+appear between them. A line comment starts with '--' or '#'
+and a block comment is enclosed by '(*' and '*)'.
 */
 
 func Parse(rd io.Reader) (p *Predicate, e error) {
+	p, e = DefaultSyntax.Parse(rd)
+	return
+}
+
+// Syntax selects the identifiers the parser accepts besides
+// the ones made of letters and digits, starting with a letter
+type Syntax struct {
+	// Underscores allows '_' in any position, like in _tmp and
+	// is_admin
+	Underscores bool
+	// Dots allows '.' after the first character, for writing
+	// paths like user.profile.admin
+	Dots bool
+	// Primes allows '\'' after the first character, like in x'
+	Primes bool
+	// Quoted allows any name enclosed by backquotes, like
+	// `first name`, with backslash escapes like the ones of Go
+	// strings and \` for a backquote
+	Quoted bool
+}
+
+// DefaultSyntax is the syntax of Parse and ParseAll, which
+// allows every kind of identifier
+var DefaultSyntax = Syntax{
+	Underscores: true,
+	Dots:        true,
+	Primes:      true,
+	Quoted:      true,
+}
+
+// Parse parses a predicate with the identifiers allowed by y
+func (y Syntax) Parse(rd io.Reader) (p *Predicate, e error) {
+	st := newPredState(rd, y)
+	p, e = st.predicate()
+	if e == nil && st.token.value != string(eof) {
+		e = &NotRecognizedErr{
+			String:    st.token.value,
+			Expecting: st.follow,
+		}
+	}
+	return
+}
+
+// Statement is a predicate read by ParseAll, with the comments
+// around it and the line where it starts. When the statement
+// is a definition, Definition is the defined name. A domain
+// declaration has a nil Predicate, its name in Domain and its
+// elements in Elements. A statement with an error has a nil
+// Predicate, and one with only comments appears at the end
+// when the input finishes with comments
+type Statement struct {
+	Predicate  *Predicate
+	Definition string
+	Domain     string
+	Elements   []string
+	// Comments are the ones before the statement
+	Comments []Comment
+	// Inline are the ones inside the predicate, attached to its
+	// operands. In a statement with an error they aren't
+	// attached
+	Inline []Comment
+	// Trailing are the ones after the last token of the
+	// statement, starting in the same line
+	Trailing []Comment
+	Line     int
+	Error    error
+}
+
+// Comment is a comment read by ParseAll, with the line and
+// column where it starts. An inline comment has the operand it
+// precedes in Before, when it's read before the operand, or
+// the one it follows in After
+type Comment struct {
+	Text          string
+	Line, Column  int
+	Before, After *Predicate
+}
+
+// ParseAll parses a sequence of predicates separated by
+// semicolons. When a statement has an error, it's recorded in
+// the returned statement and parsing continues after the next
+// semicolon
+func ParseAll(rd io.Reader) (ss []*Statement) {
+	ss = DefaultSyntax.ParseAll(rd)
+	return
+}
+
+// ParseAll parses a sequence of statements like ParseAll, with
+// the identifiers allowed by y
+func (y Syntax) ParseAll(rd io.Reader) (ss []*Statement) {
+	st := newPredState(rd, y)
+	end := false
+	// carry are the comments read after a statement that belong
+	// to the next one
+	var carry []Comment
+	var prev *Statement
+	// trail splits cs in the trailing comments of prev, which
+	// start in the line of the last token read, and the ones
+	// carried to the next statement
+	trail := func(prev *Statement, cs []Comment) {
+		for _, c := range cs {
+			if prev != nil && len(carry) == 0 && c.Line == st.lastLine {
+				prev.Trailing = append(prev.Trailing, c)
+			} else {
+				carry = append(carry, c)
+			}
+		}
+	}
+	for !end {
+		e := st.next()
+		trail(prev, st.take())
+		for e == nil && st.token.value == Semicolon {
+			e = st.next()
+			trail(prev, st.take())
+		}
+		stm := new(Statement)
+		stm.Comments, carry = carry, nil
+		if e == nil {
+			stm.Line = st.token.line
+			end = st.token.value == string(eof)
+			st.pending = !end
+		}
+		domain := e == nil && !end && st.token.value == DomainKw
+		if e == nil && !end && st.token.value == DefKw {
+			st.pending = false
+			stm.Definition, e = st.binding()
+		} else if domain {
+			st.pending = false
+			stm.Domain, e = st.binding()
+			if e == nil {
+				stm.Elements, e = st.elements()
+			}
+			if e == nil {
+				e = st.next()
+			}
+		}
+		if e == nil && !end && !domain {
+			stm.Predicate, e = st.predicate()
+		}
+		if e == nil && !end && st.token.value != Semicolon &&
+			st.token.value != string(eof) {
+			exp := []string{Semicolon}
+			if stm.Predicate != nil {
+				exp = append(st.follow, Semicolon)
+			}
+			e = &NotRecognizedErr{String: st.token.value, Expecting: exp}
+		}
+		if e != nil {
+			stm.Predicate, stm.Definition, stm.Error = nil, "", e
+			stm.Domain, stm.Elements = "", nil
+			end = st.skipStatement()
+			for _, c := range append(st.notes, st.take()...) {
+				c.Before, c.After = nil, nil
+				stm.Inline = append(stm.Inline, c)
+			}
+		} else {
+			end = end || st.token.value == string(eof)
+			// the comments after the last token of the predicate
+			// follow the statement
+			var rest []Comment
+			for _, c := range st.notes {
+				if st.inGap(c) {
+					c.Before, c.After = nil, nil
+					rest = append(rest, c)
+				} else {
+					stm.Inline = append(stm.Inline, c)
+				}
+			}
+			trail(stm, append(rest, st.take()...))
+		}
+		st.notes = nil
+		if end && len(stm.Comments) != 0 && stm.Predicate == nil &&
+			stm.Error == nil {
+			stm.Line = stm.Comments[0].Line
+		}
+		if stm.Predicate != nil || stm.Domain != "" || stm.Error != nil ||
+			len(stm.Comments) != 0 {
+			ss = append(ss, stm)
+			prev = stm
+		}
+	}
+	if len(carry) != 0 {
+		ss = append(ss, &Statement{Comments: carry, Line: carry[0].Line})
+	}
+	return
+}
+
+func newPredState(rd io.Reader, y Syntax) (s *predState) {
 	ss := []scanner{
-		identScan,
+		y.identScan,
 		spaceScan,
 		strScan(NotOp),
 		strScan(AndOp),
@@ -50,20 +279,34 @@ func Parse(rd io.Reader) (p *Predicate, e error) {
 		strScan(NotEquivalesOp),
 		strScan(ImpliesOp),
 		strScan(FollowsOp),
-		strScan(OPar),
+		strScan(XorOp),
+		strScan(NandOp),
+		strScan(NorOp),
+		strScan(EqOp),
+		strScan(NeOp),
+		strScan(LtOp),
+		strScan(LeOp),
+		strScan(GtOp),
+		strScan(GeOp),
+		quoteScan('"'),
+		quoteScan('`'),
+		strScan(ForallOp),
+		strScan(ExistsOp),
+		strScan(InOp),
+		parScan,
 		strScan(CPar),
+		strScan(OBrace),
+		strScan(CBrace),
+		strScan(Semicolon),
+		strScan(Comma),
+		colonScan,
+		numScan,
+		lineCommentScan("--"),
+		lineCommentScan("#"),
 	}
-
-	st := &predState{
-		tkf: tokens(rd, ss),
-	}
-
-	p, e = st.predicate()
-	if e == nil && st.token.value != string(eof) {
-		e = &NotRecognizedErr{
-			String:    st.token.value,
-			Expecting: []string{p.Operator},
-		}
+	s = &predState{
+		tkf:    tokens(rd, ss),
+		syntax: y,
 	}
 	return
 }
@@ -71,21 +314,104 @@ func Parse(rd io.Reader) (p *Predicate, e error) {
 type predState struct {
 	tkf   func() (*token, error)
 	token *token
+	// pending makes next keep the current token, which was
+	// read ahead
+	pending bool
+	// loose are the comments read and not attached yet, and
+	// notes the ones attached to the operands of the current
+	// statement
+	loose, notes []Comment
+	// gap are the comments between the current token and the
+	// previous one, whose line is lastLine
+	gap      []Comment
+	lastLine int
+	syntax   Syntax
+	// follow are the operators that could continue the last
+	// predicate parsed, from the innermost level to the outermost
+	follow []string
 }
 
 func (s *predState) next() (e error) {
-	s.token, e = s.tkf()
-	if e == nil && s.token.value == "" {
+	if s.pending {
+		s.pending = false
+	} else {
+		if s.token != nil {
+			s.lastLine = s.token.line
+		}
+		s.gap = nil
 		s.token, e = s.tkf()
+		for e == nil && (s.token.value == "" || s.token.isComment) {
+			if s.token.isComment {
+				c := Comment{
+					Text:   s.token.value,
+					Line:   s.token.line,
+					Column: s.token.column,
+				}
+				s.loose, s.gap = append(s.loose, c), append(s.gap, c)
+			}
+			s.token, e = s.tkf()
+		}
 	}
 	return
 }
 
+// take returns the comments not attached yet
+func (s *predState) take() (cs []Comment) {
+	cs, s.loose = s.loose, nil
+	return
+}
+
+// attach attaches cs to the operands before or after
+func (s *predState) attach(cs []Comment, before, after *Predicate) {
+	for _, c := range cs {
+		c.Before, c.After = before, after
+		s.notes = append(s.notes, c)
+	}
+}
+
+// inGap tells whether c is between the current token and the
+// previous one
+func (s *predState) inGap(c Comment) (ok bool) {
+	for _, g := range s.gap {
+		ok = ok || (g.Line == c.Line && g.Column == c.Column)
+	}
+	return
+}
+
+// moved makes the comments attached to p refer to q, which
+// holds the former content of p
+func (s *predState) moved(p, q *Predicate) {
+	for i := range s.notes {
+		if s.notes[i].Before == p {
+			s.notes[i].Before = q
+		}
+		if s.notes[i].After == p {
+			s.notes[i].After = q
+		}
+	}
+}
+
+// skipStatement advances until the end of the current
+// statement, returning whether the end of the input was found
+func (s *predState) skipStatement() (end bool) {
+	atEnd := func() bool {
+		return s.token != nil && (s.token.value == Semicolon ||
+			s.token.value == string(eof))
+	}
+	for !atEnd() {
+		s.next()
+	}
+	end = s.token.value == string(eof)
+	return
+}
+
 func (s *predState) predicate() (p *Predicate, e error) {
 	factor := s.factor()
-	junction := s.parseOp("junction", factor, false, OrOp, AndOp)
+	junction := s.parseOp("junction", factor, false, OrOp, AndOp,
+		NandOp, NorOp)
 	term := s.parseOp("term", junction, false, ImpliesOp, FollowsOp)
-	p, e = s.parseOp("predicate", term, true, EquivalesOp, NotEquivalesOp)()
+	p, e = s.parseOp("predicate", term, true, EquivalesOp,
+		NotEquivalesOp, XorOp)()
 	return
 }
 
@@ -97,27 +423,36 @@ func (s *predState) parseOp(
 		p, e = sym()
 		if e == nil {
 			var o string
-			o, e = s.moreOps(ops)
+			// allowed is local to this call, since the returned
+			// function parses every operand of the enclosing
+			// level, and narrowing ops would keep the operator
+			// detected in one operand for the next ones
+			allowed := ops
+			o, e = s.moreOps(allowed)
 			if e == nil && o != "" && !mixAlt {
 				// restrict the set of operators to the detected
-				ops = []string{o}
+				allowed = []string{o}
+			}
+			if o == NandOp || o == NorOp {
+				// they aren't associative
+				allowed = nil
 			}
 			curr := p
 			for e == nil && o != "" {
 				var b *Predicate
 				b, e = sym()
 				if e == nil {
-					old := &Predicate{
-						Operator: curr.Operator,
-						A:        curr.A,
-						B:        curr.B,
-						String:   curr.String,
-					}
-					curr.Operator, curr.A, curr.B, curr.String = o, old, b, ""
+					old := new(Predicate)
+					*old = *curr
+					s.moved(curr, old)
+					*curr = Predicate{Operator: o, A: old, B: b}
 					curr = curr.B
-					o, e = s.moreOps(ops)
+					o, e = s.moreOps(allowed)
 				}
 			}
+			if e == nil {
+				s.follow = append(s.follow, allowed...)
+			}
 		}
 		return
 	}
@@ -139,15 +474,48 @@ func (s *predState) moreOps(ops []string) (
 func (s *predState) factor() func() (*Predicate, error) {
 	return func() (p *Predicate, e error) {
 		e = s.next()
+		// before are the comments preceding the factor and inner
+		// the ones between its negation and its operand
+		before := s.take()
+		var inner []Comment
 		var nt *Predicate
+		// let is true when the factor is a let expression, a
+		// conditional, a quantifier or an identifier, whose
+		// parsing already read the following token
+		let := false
+		// open is true when the factor ends with a predicate, like
+		// the body of a let, which took every operator it could
+		open := false
 		if e == nil {
 			if s.token.value == NotOp {
 				nt = &Predicate{Operator: NotOp}
 				e = s.next()
+				inner = s.take()
+			}
+			var name string
+			isName := false
+			if e == nil {
+				name, isName = s.nameToken()
 			}
 			if e == nil {
-				if s.token.isIdent {
-					p = &Predicate{Operator: Term, String: s.token.value}
+				if s.token.isIdent && s.token.value == LetOp {
+					p, e = s.let()
+					let, open = true, true
+				} else if s.token.isIdent && s.token.value == IfOp {
+					p, e = s.conditional()
+					let, open = true, true
+				} else if s.token.isIdent && isCard(s.token.value) {
+					p, e = s.card(s.token.value)
+				} else if isName {
+					p, e = s.atom(name)
+					let = true
+				} else if s.token.isNumber || s.token.isString ||
+					s.token.value == "-" {
+					p, e = s.literalComparison()
+					let = true
+				} else if isQuant(s.token.value) {
+					p, e = s.quantifier(s.token.value)
+					let, open = true, true
 				} else if s.token.value == OPar {
 					p, e = s.predicate()
 					if e == nil && s.token.value != CPar {
@@ -164,33 +532,422 @@ func (s *predState) factor() func() (*Predicate, error) {
 				}
 			}
 		}
+		if e == nil {
+			s.attach(inner, p, nil)
+		}
 		if e == nil && nt != nil {
 			nt.B = p
 			p = nt
 		}
-		if e == nil {
+		if e == nil && open && isBinary(s.token.value) {
+			// the operator would take the factor as operand, while
+			// String prints it as part of the last predicate
+			e = &NotRecognizedErr{
+				String:    s.token.value,
+				Expecting: s.follow,
+			}
+		}
+		if e == nil && !let {
 			e = s.next()
 		}
+		if e == nil {
+			s.attach(before, p, nil)
+			s.attach(s.take(), nil, p)
+		}
+		// the levels enclosing the factor start again
+		s.follow = nil
 		return
 	}
 }
 
+// let parses a let expression after reading its keyword
+func (s *predState) let() (p *Predicate, e error) {
+	var name string
+	name, e = s.binding()
+	if e == nil {
+		p = &Predicate{Operator: LetOp, String: name}
+		p.A, e = s.predicate()
+	}
+	if e == nil && s.token.value != InKw {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{InKw},
+		}
+	}
+	if e == nil {
+		p.B, e = s.predicate()
+	}
+	return
+}
+
+// conditional parses an if expression after reading its
+// keyword
+func (s *predState) conditional() (p *Predicate, e error) {
+	p = &Predicate{Operator: IfOp}
+	parts := []struct {
+		dst *(*Predicate)
+		kw  string
+	}{
+		{&p.A, ThenKw},
+		{&p.B, ElseKw},
+		{&p.C, ""},
+	}
+	for i := 0; e == nil && i != len(parts); i++ {
+		*parts[i].dst, e = s.predicate()
+		if e == nil && parts[i].kw != "" &&
+			s.token.value != parts[i].kw {
+			e = &NotRecognizedErr{
+				String:    s.token.value,
+				Expecting: []string{parts[i].kw},
+			}
+		}
+	}
+	return
+}
+
+// card parses a cardinality constraint after reading its
+// operator
+func (s *predState) card(op string) (p *Predicate, e error) {
+	p = &Predicate{Operator: op}
+	e = s.expect(OPar)
+	if e == nil {
+		e = s.next()
+	}
+	var k error
+	if e == nil && s.token.isNumber {
+		p.K, k = strconv.Atoi(s.token.value)
+	}
+	if e == nil && (!s.token.isNumber || k != nil) {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{Number},
+		}
+	}
+	if e == nil {
+		e = s.expect(Semicolon)
+	}
+	for e == nil && s.token.value != CPar {
+		var a *Predicate
+		a, e = s.predicate()
+		if e == nil {
+			p.Args = append(p.Args, a)
+			if s.token.value != Comma && s.token.value != CPar {
+				e = &NotRecognizedErr{
+					String:    s.token.value,
+					Expecting: []string{Comma, CPar},
+				}
+			}
+		}
+	}
+	return
+}
+
+// atom parses a term or, when name is followed by an opening
+// parenthesis, an atom with parameters, and when it's followed
+// by a comparison operator, a comparison. It reads the token
+// after them
+func (s *predState) atom(name string) (p *Predicate, e error) {
+	p = NewTerm(name)
+	e = s.next()
+	if e == nil && s.token.value == OPar {
+		p.Operator = AtomOp
+		p.Args, e = s.list(CPar, s.identifier)
+		if e == nil {
+			e = s.next()
+		}
+	} else if e == nil && isComparison(s.token.value) {
+		p, e = s.comparison(&Predicate{Operator: VarOp, String: name})
+	}
+	return
+}
+
+// literalComparison parses a comparison whose first operand is
+// a literal, which is the current token
+func (s *predState) literalComparison() (p *Predicate, e error) {
+	var a *Predicate
+	a, e = s.literal()
+	if e == nil {
+		e = s.next()
+	}
+	if e == nil && isComparison(s.token.value) {
+		p, e = s.comparison(a)
+	} else if e == nil {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{EqOp, NeOp, LtOp, LeOp, GtOp, GeOp, InOp},
+		}
+	}
+	return
+}
+
+// comparison parses a comparison whose first operand is a, when
+// the current token is its operator. It reads the token after
+// the comparison
+func (s *predState) comparison(a *Predicate) (p *Predicate,
+	e error) {
+	p = &Predicate{Operator: s.token.value, A: a}
+	if p.Operator == InOp {
+		e = s.expect(OBrace)
+		if e == nil {
+			p.Args, e = s.list(CBrace, s.literal)
+		}
+		if e == nil && p.Args == nil {
+			p.Args = []*Predicate{}
+		}
+	} else {
+		e = s.next()
+		if e == nil {
+			p.B, e = s.operand()
+		}
+	}
+	if e == nil {
+		e = s.next()
+	}
+	return
+}
+
+// operand parses the current token as a variable or a literal
+func (s *predState) operand() (p *Predicate, e error) {
+	if n, ok := s.nameToken(); ok {
+		p = &Predicate{Operator: VarOp, String: n}
+	} else {
+		p, e = s.literal()
+	}
+	return
+}
+
+// literal parses the current token as a number, maybe negative,
+// or a string
+func (s *predState) literal() (p *Predicate, e error) {
+	minus := s.token.value == "-"
+	if minus {
+		e = s.next()
+	}
+	if e == nil && s.token.isNumber {
+		p = &Predicate{Operator: NumberOp, String: s.token.value}
+		if minus {
+			p.String = "-" + p.String
+		}
+	} else if e == nil && s.token.isString && !minus {
+		var v string
+		v, e = strconv.Unquote(s.token.value)
+		p = NewString(v)
+		if e != nil {
+			p, e = nil, &NotRecognizedErr{
+				String:    s.token.value,
+				Expecting: []string{StringLit},
+			}
+		}
+	} else if e == nil {
+		exp := []string{Number, StringLit}
+		if minus {
+			exp = []string{Number}
+		}
+		e = &NotRecognizedErr{String: s.token.value, Expecting: exp}
+	}
+	return
+}
+
+// quantifier parses a quantified predicate after reading its
+// operator
+func (s *predState) quantifier(op string) (p *Predicate, e error) {
+	p = &Predicate{Operator: op}
+	p.String, e = s.name()
+	if e == nil {
+		e = s.expect(InOp)
+	}
+	if e == nil {
+		e = s.next()
+	}
+	if e == nil && s.token.value == OBrace {
+		s.pending = true
+		var es []string
+		es, e = s.elements()
+		for _, x := range es {
+			p.Args = append(p.Args, NewTerm(x))
+		}
+		if e == nil && p.Args == nil {
+			// an empty domain is represented by an empty slice
+			p.Args = []*Predicate{}
+		}
+	} else if e == nil {
+		var ok bool
+		p.Domain, ok = s.nameToken()
+		if !ok {
+			e = &NotRecognizedErr{
+				String:    s.token.value,
+				Expecting: []string{Identifier, OBrace},
+			}
+		}
+	}
+	if e == nil {
+		e = s.expect(Colon)
+	}
+	if e == nil {
+		p.B, e = s.predicate()
+	}
+	return
+}
+
+// elements reads a set of identifiers between braces
+func (s *predState) elements() (es []string, e error) {
+	e = s.expect(OBrace)
+	var ps []*Predicate
+	if e == nil {
+		ps, e = s.list(CBrace, s.identifier)
+	}
+	for _, p := range ps {
+		es = append(es, p.String)
+	}
+	return
+}
+
+// list reads the items parsed by item, separated by commas,
+// until the closing token end, which may follow the opening one
+// immediately only when it's a brace
+func (s *predState) list(end string,
+	item func() (*Predicate, error)) (ps []*Predicate, e error) {
+	e = s.next()
+	done := e == nil && end == CBrace && s.token.value == end
+	for e == nil && !done {
+		var p *Predicate
+		p, e = item()
+		if e == nil {
+			ps = append(ps, p)
+			e = s.next()
+		}
+		if e == nil && s.token.value == Comma {
+			e = s.next()
+		} else if e == nil && s.token.value == end {
+			done = true
+		} else if e == nil {
+			e = &NotRecognizedErr{
+				String:    s.token.value,
+				Expecting: []string{Comma, end},
+			}
+		}
+	}
+	return
+}
+
+// identifier parses the current token as a term, which can't
+// be a keyword
+func (s *predState) identifier() (p *Predicate, e error) {
+	if n, ok := s.nameToken(); ok {
+		p = NewTerm(n)
+	} else {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{Identifier},
+		}
+	}
+	return
+}
+
+// nameToken returns the name in the current token when it's an
+// identifier that isn't a keyword, or a quoted name allowed by
+// the syntax
+func (s *predState) nameToken() (n string, ok bool) {
+	if s.token.isIdent && !isKeyword(s.token.value) {
+		n, ok = s.token.value, true
+	} else if s.token.isQuoted && s.syntax.Quoted {
+		var e error
+		n, e = unquoteName(s.token.value)
+		ok = e == nil
+	}
+	return
+}
+
+// name reads an identifier that isn't a keyword
+func (s *predState) name() (n string, e error) {
+	e = s.next()
+	var p *Predicate
+	if e == nil {
+		p, e = s.identifier()
+	}
+	if e == nil {
+		n = p.String
+	}
+	return
+}
+
+// expect reads the next token, returning an error if it isn't
+// v
+func (s *predState) expect(v string) (e error) {
+	e = s.next()
+	if e == nil && s.token.value != v {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{v},
+		}
+	}
+	return
+}
+
+// binding reads the identifier and the assignment operator
+// that follow the keywords let, def and domain
+func (s *predState) binding() (name string, e error) {
+	name, e = s.name()
+	if e == nil {
+		e = s.next()
+	}
+	if e == nil && s.token.value != AssignOp {
+		e = &NotRecognizedErr{
+			String:    s.token.value,
+			Expecting: []string{AssignOp},
+		}
+	}
+	return
+}
+
+func isBinary(s string) (ok bool) {
+	ok = s == OrOp || s == AndOp || s == NandOp || s == NorOp ||
+		s == ImpliesOp || s == FollowsOp || s == EquivalesOp ||
+		s == NotEquivalesOp || s == XorOp
+	return
+}
+
+func isKeyword(s string) (ok bool) {
+	ok = s == LetOp || s == InKw || s == DefKw || s == IfOp ||
+		s == ThenKw || s == ElseKw || s == DomainKw || isCard(s)
+	return
+}
+
 const (
 	OPar           = "("
 	CPar           = ")"
+	Semicolon      = ";"
+	Comma          = ","
+	Number         = "number"
+	StringLit      = "string"
+	OBrace         = "{"
+	CBrace         = "}"
+	Colon          = ":"
+	AssignOp       = ":="
+	InOp           = "∈" // C-k (-
+	DefKw          = "def"
+	DomainKw       = "domain"
+	InKw           = "in"
+	ThenKw         = "then"
+	ElseKw         = "else"
 	Identifier     = "identifier"
 	SupportedToken = "supported token"
 )
 
 type token struct {
-	value    string
-	isIdent  bool
-	isNumber bool
+	value     string
+	isIdent   bool
+	isNumber  bool
+	isString  bool
+	isQuoted  bool
+	isComment bool
+	line      int
+	column    int
 }
 
 const (
 	// 0x3 is the end of file character
-	eof = 0x3
+	eof rune = 0x3
 )
 
 func tokens(source io.Reader, ss []scanner) (
@@ -200,12 +957,18 @@ func tokens(source io.Reader, ss []scanner) (
 	var rn rune
 	var sc func(rune) (*token, bool, bool)
 	n, end, read, search, scan := 0, false, true, false, false
+	line, col, prev := 1, 0, rune(0)
 	tf = func() (t *token, e error) {
 		if end {
 			t = &token{value: string(eof)}
 		}
-		for !end {
+		done, first, firstCol := end, 0, 0
+		for !done {
 			if read {
+				if prev == '\n' {
+					line, col = line+1, 0
+				}
+				col = col + 1
 				rn, _, e = rd.ReadRune()
 				if e != nil {
 					rn = eof
@@ -213,25 +976,34 @@ func tokens(source io.Reader, ss []scanner) (
 						e = nil
 					}
 				}
+				prev = rn
 				read, search = false, !scan
 			} else if search {
 				if n == len(ss) {
-					e, end =
+					// the unrecognized rune is skipped, allowing to
+					// continue scanning after the error
+					e, done, read, search =
 						&NotRecognizedErr{
 							String:    string(rn),
 							Expecting: []string{SupportedToken},
 						},
-						true
+						true, true, false
 				} else {
 					sc, n, search = ss[n](), n+1, false
 				}
 			} else if !search {
-				t, read, end = sc(rn)
-				search = !read || end
+				if first == 0 {
+					first, firstCol = line, col
+				}
+				t, read, done = sc(rn)
+				search = !read || done
 				scan = !search
 			}
 		}
-		n, end = 0, e != nil || t.value == string(eof)
+		n, end = 0, t != nil && t.value == string(eof)
+		if t != nil && !end {
+			t.line, t.column = first, firstCol
+		}
 		return
 	}
 	return
@@ -250,11 +1022,20 @@ func (n *NotRecognizedErr) Error() (s string) {
 
 type scanner func() func(rune) (*token, bool, bool)
 
+// identScan scans an identifier of the default syntax
 func identScan() func(rune) (*token, bool, bool) {
+	return DefaultSyntax.identScan()
+}
+
+// identScan scans an identifier, which is a letter followed by
+// letters and digits, with the underscores, dots and primes
+// allowed by y
+func (y Syntax) identScan() func(rune) (*token, bool, bool) {
 	var ident string
 	return func(rn rune) (t *token, cont, prod bool) {
-		cont = unicode.IsLetter(rn) ||
-			(ident != "" && unicode.IsDigit(rn))
+		cont = unicode.IsLetter(rn) || (y.Underscores && rn == '_') ||
+			(ident != "" && (unicode.IsDigit(rn) ||
+				(y.Dots && rn == '.') || (y.Primes && rn == '\'')))
 		if cont {
 			ident = ident + string(rn)
 		} else if ident != "" {
@@ -264,6 +1045,101 @@ func identScan() func(rune) (*token, bool, bool) {
 	}
 }
 
+// numScan scans a number, with an optional decimal part
+func numScan() func(rune) (*token, bool, bool) {
+	var num string
+	point := false
+	return func(rn rune) (t *token, cont, prod bool) {
+		cont = unicode.IsDigit(rn) ||
+			(rn == '.' && num != "" && !point)
+		if cont {
+			num, point = num+string(rn), point || rn == '.'
+		} else if num != "" {
+			t, prod = &token{value: num, isNumber: true}, true
+		}
+		return
+	}
+}
+
+// quoteScan scans text enclosed by q, with backslash escapes,
+// which is a string when q is a double quote and a quoted name
+// when it's a backquote. The token value keeps the quotes and
+// escapes
+func quoteScan(q rune) func() func(rune) (*token, bool, bool) {
+	return func() func(rune) (*token, bool, bool) {
+		var sb strings.Builder
+		// esc is true after a backslash inside the text
+		esc := false
+		tk := func() *token {
+			return &token{value: sb.String(), isString: q == '"',
+				isQuoted: q == '`'}
+		}
+		return func(rn rune) (t *token, cont, prod bool) {
+			if sb.Len() == 0 {
+				cont = rn == q
+			} else if rn == eof || rn == '\n' {
+				// unterminated text, which the parser rejects
+				t, prod = tk(), true
+			} else {
+				cont = true
+				prod = rn == q && !esc
+				esc = rn == '\\' && !esc
+			}
+			if cont {
+				sb.WriteRune(rn)
+			}
+			if prod && cont {
+				t = tk()
+			}
+			return
+		}
+	}
+}
+
+// unquoteName returns the name in s, written by quoteName
+func unquoteName(s string) (n string, e error) {
+	rs := []rune(s)
+	ok := len(rs) >= 2 && rs[0] == '`' && rs[len(rs)-1] == '`'
+	// the name is translated to a Go string literal
+	var sb strings.Builder
+	sb.WriteRune('"')
+	esc := false
+	for i := 1; ok && i != len(rs)-1; i++ {
+		rn := rs[i]
+		if esc && rn != '`' && rn != '"' {
+			sb.WriteRune('\\')
+		}
+		if rn == '"' {
+			sb.WriteString(`\"`)
+		} else if esc || rn != '\\' {
+			sb.WriteRune(rn)
+		}
+		esc = rn == '\\' && !esc
+	}
+	sb.WriteRune('"')
+	if ok && !esc {
+		n, e = strconv.Unquote(sb.String())
+	} else {
+		e = strconv.ErrSyntax
+	}
+	return
+}
+
+// colonScan scans a colon or the assignment operator
+func colonScan() func(rune) (*token, bool, bool) {
+	colon := false
+	return func(rn rune) (t *token, cont, prod bool) {
+		if !colon {
+			cont, colon = rn == ':', rn == ':'
+		} else if rn == '=' {
+			t, cont, prod = &token{value: AssignOp}, true, true
+		} else {
+			t, prod = &token{value: Colon}, true
+		}
+		return
+	}
+}
+
 func strScan(strScan string) (s scanner) {
 	s = func() func(rune) (*token, bool, bool) {
 		str := strScan
@@ -298,6 +1174,65 @@ func spaceScan() func(rune) (*token, bool, bool) {
 	}
 }
 
+// parScan scans an opening parenthesis, or a block comment
+// if it's followed by '*'
+func parScan() func(rune) (*token, bool, bool) {
+	var sb strings.Builder
+	// comment is true after reading "(*" and star after reading
+	// '*' inside the comment
+	par, comment, star := false, false, false
+	return func(rn rune) (t *token, cont, prod bool) {
+		if !par {
+			cont, par = rn == '(', rn == '('
+		} else if !comment && rn != '*' {
+			t, prod = &token{value: OPar}, true
+		} else if comment && rn == eof {
+			// unterminated comment, which the parser rejects
+			t, prod = &token{value: sb.String()}, true
+		} else {
+			cont = true
+			if star && rn == ')' {
+				t, prod = &token{value: sb.String() + ")",
+					isComment: true}, true
+			}
+			comment, star = true, rn == '*'
+		}
+		if cont && !prod {
+			sb.WriteRune(rn)
+		}
+		return
+	}
+}
+
+// lineCommentScan scans a comment starting with start and
+// ending before the next newline
+func lineCommentScan(start string) (s scanner) {
+	s = func() func(rune) (*token, bool, bool) {
+		var sb strings.Builder
+		return func(rn rune) (t *token, cont, prod bool) {
+			if sb.Len() < len(start) {
+				sr, _ := utf8.DecodeRuneInString(start[sb.Len():])
+				cont = sr == rn
+				if !cont && sb.Len() != 0 {
+					// a prefix of start isn't a valid token
+					t, prod = &token{value: sb.String()}, true
+				}
+			} else {
+				cont = rn != '\n' && rn != eof
+				prod = !cont
+				if prod {
+					t = &token{value: sb.String(), isComment: true}
+				}
+			}
+			if cont {
+				sb.WriteRune(rn)
+			}
+			return
+		}
+	}
+	return
+}
+
 func eofScan() func(rune) (*token, bool, bool) {
 	return func(r rune) (t *token, cont, prod bool) {
 		if r == eof {
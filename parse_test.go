@@ -104,6 +104,100 @@ func TestIdentScan(t *testing.T) {
 	require.False(t, prod)
 }
 
+func TestSyntax(t *testing.T) {
+	ps := []struct {
+		pred, str string
+		vars      []string
+	}{
+		{"is_admin ∧ _tmp", "is_admin ∧ _tmp", []string{"_tmp", "is_admin"}},
+		{"x' ≡ ¬x", "x' ≡ ¬x", []string{"x", "x'"}},
+		{"user.profile.admin", "user.profile.admin",
+			[]string{"user.profile.admin"}},
+		{"`first name` ∨ `let`", "`first name` ∨ `let`",
+			[]string{"first name", "let"}},
+		{"`a\\`b\\\"` ∧ `x`", "`a\\`b\"` ∧ x", []string{"a`b\"", "x"}},
+		{"let `a b` := A in `a b`", "let `a b` := A in `a b`",
+			[]string{"A"}},
+		{"`user age` ≥ 18 ∧ `e-mail` = \"x@y\"",
+			"`user age` ≥ 18 ∧ `e-mail` = \"x@y\"", nil},
+		{"n ≤ `max n`", "n ≤ `max n`", nil},
+		{"∀ `u 1` ∈ {`a b`, c} : `p q`(`u 1`)",
+			"∀ `u 1` ∈ {`a b`, c} : `p q`(`u 1`)", nil},
+		{`"CU" = country ∧ "a" ≠ "b"`, `"CU" = country ∧ "a" ≠ "b"`, nil},
+		{"`a b` = `c d`", "`a b` = `c d`", nil},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid(), "At %d", i)
+		require.Equal(t, ps[i].str, String(p), "At %d", i)
+		require.Equal(t, ps[i].vars, Vars(p), "At %d", i)
+		q, e := Parse(strings.NewReader(String(p)))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, String(p), String(q), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	plain := Syntax{}
+	for _, x := range []string{"a_b", "x'", "a.b", "`a b`", `"a b"`,
+		"`a b", "`a\\`"} {
+		_, e := plain.Parse(strings.NewReader(x))
+		require.Error(t, e, x)
+	}
+	p, e := plain.Parse(strings.NewReader(`"x" = a`))
+	require.NoError(t, e)
+	require.Equal(t, StringOp, p.A.Operator)
+	require.Equal(t, VarOp, p.B.Operator)
+	require.Equal(t, `"x" = a`, String(p))
+	p, e = Parse(strings.NewReader("`x` = \"a\""))
+	require.NoError(t, e)
+	require.Equal(t, VarOp, p.A.Operator)
+	require.Equal(t, StringOp, p.B.Operator)
+	ps1 := []*Predicate{
+		{Operator: LeOp,
+			A: &Predicate{Operator: VarOp, String: "n"},
+			B: &Predicate{Operator: VarOp, String: "max n"}},
+		{Operator: EqOp,
+			A: &Predicate{Operator: VarOp, String: "a b"},
+			B: &Predicate{Operator: VarOp, String: "c d"}},
+		{Operator: LtOp, A: NewString("a b"), B: NewString("c")},
+		{Operator: GeOp, A: NewString("a"),
+			B: &Predicate{Operator: VarOp, String: "b"}},
+	}
+	for _, p := range ps1 {
+		q, e := Parse(strings.NewReader(String(p)))
+		require.NoError(t, e, String(p))
+		require.Equal(t, p, q, String(p))
+	}
+	p, e = Syntax{Primes: true}.Parse(strings.NewReader("x' ∧ y"))
+	require.NoError(t, e)
+	require.Equal(t, "x' ∧ y", String(p))
+}
+
+func TestQuoteName(t *testing.T) {
+	ps := [][]string{
+		{"a", "a"},
+		{"true", "true"},
+		{"a_1.b'", "a_1.b'"},
+		{"_", "_"},
+		{"1x", "`1x`"},
+		{".a", "`.a`"},
+		{"'a", "`'a`"},
+		{"in", "`in`"},
+		{"", "``"},
+		{"a\nb", "`a\\nb`"},
+		{"a\"b", "`a\"b`"},
+		{"a`b\\", "`a\\`b\\\\`"},
+	}
+	inf := func(i int) {
+		require.Equal(t, ps[i][1], quoteName(ps[i][0]), "At %d", i)
+		p, e := Parse(strings.NewReader(String(NewTerm(ps[i][0]))))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, NewTerm(ps[i][0]), p, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
 func TestParseOp(t *testing.T) {
 	ps := []string{"a", "¬a", "a ∧ b", "a ∧ b ∧ ¬c ∧ d"}
 	ss := []scanner{spaceScan, identScan, strScan(AndOp),
@@ -118,13 +212,31 @@ func TestParseOp(t *testing.T) {
 	alg.Forall(inf, len(ps))
 }
 
+func TestParseOpReuse(t *testing.T) {
+	// each call of the junction parser detects its own operator,
+	// instead of keeping the one detected by a previous call
+	ps := []string{"A ∧ B ⇒ C ∨ D", "A ∨ B ≡ C ∧ D ≡ E ↑ F"}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i]))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i], String(p), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	p, e := Parse(strings.NewReader("A ∧ B ⇒ C ∨ D"))
+	require.NoError(t, e)
+	require.Equal(t, OrOp, p.B.Operator)
+}
+
 func TestParse(t *testing.T) {
 	errEof := &NotRecognizedErr{
 		String:    string(eof),
 		Expecting: []string{Identifier, OPar},
 	}
-	errAnd := &NotRecognizedErr{String: "∧", Expecting: []string{"∨"}}
-	errFl := &NotRecognizedErr{String: "⇐", Expecting: []string{"⇒"}}
+	errAnd := &NotRecognizedErr{String: "∧", Expecting: []string{
+		OrOp, ImpliesOp, FollowsOp, EquivalesOp, NotEquivalesOp, XorOp}}
+	errFl := &NotRecognizedErr{String: "⇐", Expecting: []string{
+		OrOp, AndOp, NandOp, NorOp, ImpliesOp, EquivalesOp,
+		NotEquivalesOp, XorOp}}
 	ps := []struct {
 		pred string
 		e    error
@@ -144,6 +256,8 @@ func TestParse(t *testing.T) {
 		{"A ∨ (B ∧ C)", nil},
 		{"A ⇒ (B ⇐ C)", nil},
 		{"a ≡ b ≢ c ≡ ¬x ∧ (¬z ≡ y) ≢ true", nil},
+		{"A ∧ B ≡ C ∨ D", nil},
+		{"A ∨ ¬B ⇒ C ∧ D ≡ E", nil},
 	}
 	inf := func(i int) {
 		np, e := Parse(strings.NewReader(ps[i].pred))
@@ -164,3 +278,124 @@ func TestParse(t *testing.T) {
 	}
 	alg.Forall(inf, len(ps))
 }
+
+func TestComments(t *testing.T) {
+	ps := []struct {
+		pred, str string
+	}{
+		{"A ∧ -- first\nB", "A ∧ B"},
+		{"# header\nA\n∨\n(* block\n comment *) B", "A ∨ B"},
+		{"(* (A) **)(A ⇒ B)", "A ⇒ B"},
+		{"¬(* not *)(A ≡ B)", "¬(A ≡ B)"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].str, String(p), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	_, e := Parse(strings.NewReader("A ∧ (* unterminated"))
+	require.Equal(t, &NotRecognizedErr{
+		String:    "(* unterminated",
+		Expecting: []string{Identifier, OPar},
+	}, e)
+	_, e = Parse(strings.NewReader("A ∧ B - C"))
+	require.Equal(t, &NotRecognizedErr{
+		String: "-",
+		Expecting: []string{AndOp, ImpliesOp, FollowsOp, EquivalesOp,
+			NotEquivalesOp, XorOp},
+	}, e)
+}
+
+func TestParseAll(t *testing.T) {
+	txt := "-- rule one\nA ∧ B;\n\n" +
+		"A ∨\n  ¬C ; ;\n" +
+		"A ∨ B ∧ C; # mixed\n" +
+		"D ⇒ @E;\n" +
+		"(A ≡ B)\n" +
+		"-- the end"
+	ss := ParseAll(strings.NewReader(txt))
+	require.Len(t, ss, 6)
+	ps := []struct {
+		pred     string
+		comments []string
+		trailing []string
+		line     int
+		e        error
+	}{
+		{"A ∧ B", []string{"-- rule one"}, nil, 2, nil},
+		{"A ∨ ¬C", nil, nil, 4, nil},
+		{"", nil, []string{"# mixed"}, 6,
+			&NotRecognizedErr{
+				String: "∧",
+				Expecting: []string{OrOp, ImpliesOp, FollowsOp,
+					EquivalesOp, NotEquivalesOp, XorOp, Semicolon},
+			}},
+		{"", nil, nil, 7, &NotRecognizedErr{
+			String:    "@",
+			Expecting: []string{SupportedToken},
+		}},
+		{"A ≡ B", nil, nil, 8, nil},
+		{"", []string{"-- the end"}, nil, 9, nil},
+	}
+	inf := func(i int) {
+		s := ss[i]
+		require.Equal(t, ps[i].e, s.Error, "At %d", i)
+		if ps[i].pred != "" {
+			require.Equal(t, ps[i].pred, String(s.Predicate), "At %d", i)
+		} else {
+			require.Nil(t, s.Predicate, "At %d", i)
+		}
+		require.Equal(t, ps[i].comments, texts(s.Comments), "At %d", i)
+		require.Equal(t, ps[i].trailing, texts(s.Trailing), "At %d", i)
+		require.Empty(t, s.Inline, "At %d", i)
+		require.Equal(t, ps[i].line, s.Line, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	require.Equal(t, 1, ss[0].Comments[0].Column)
+	require.Equal(t, 6, ss[2].Trailing[0].Line)
+	require.Equal(t, 12, ss[2].Trailing[0].Column)
+	require.Empty(t, ParseAll(strings.NewReader(" ; \n")))
+	ss = ParseAll(strings.NewReader("A;\n(* the end *)"))
+	require.Len(t, ss, 2)
+	require.Nil(t, ss[1].Predicate)
+	require.Equal(t, []string{"(* the end *)"}, texts(ss[1].Comments))
+}
+
+func TestInlineComments(t *testing.T) {
+	txt := "A ∧ -- first\nB ∧ C; -- why\n" +
+		"(* lead *) (A ∨ B) (* c *) ∧ ¬(* n *) C -- end\n;\n" +
+		"A (* a *) ⇒ B"
+	ss := ParseAll(strings.NewReader(txt))
+	require.Len(t, ss, 3)
+	p, q, r := ss[0].Predicate, ss[1].Predicate, ss[2].Predicate
+	ps := []struct {
+		c             Comment
+		before, after *Predicate
+	}{
+		{ss[0].Inline[0], p.B.A, nil},
+		{ss[1].Inline[0], nil, q.A},
+		{ss[1].Inline[1], q.B.B, nil},
+		{ss[2].Inline[0], nil, r.A},
+	}
+	inf := func(i int) {
+		require.True(t, ps[i].before == ps[i].c.Before, "At %d", i)
+		require.True(t, ps[i].after == ps[i].c.After, "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	require.Equal(t, []string{"-- first"}, texts(ss[0].Inline))
+	require.Equal(t, Comment{Text: "-- why", Line: 2, Column: 8},
+		ss[0].Trailing[0])
+	require.Equal(t, []string{"(* lead *)"}, texts(ss[1].Comments))
+	require.Equal(t, []string{"(* c *)", "(* n *)"}, texts(ss[1].Inline))
+	require.Equal(t, []string{"-- end"}, texts(ss[1].Trailing))
+	require.Equal(t, []string{"(* a *)"}, texts(ss[2].Inline))
+}
+
+func texts(cs []Comment) (ts []string) {
+	for _, c := range cs {
+		ts = append(ts, c.Text)
+	}
+	return
+}
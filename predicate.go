@@ -23,6 +23,10 @@ package predicate
 import (
 	"fmt"
 	alg "github.com/lamg/algorithms"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 type Predicate struct {
@@ -30,7 +34,16 @@ type Predicate struct {
 	A        *Predicate `json:"a"`
 	B        *Predicate `json:"b"`
 	String   string     `json:"string"`
-	AltRef   int        `json:"-"`
+	// C is the else branch of conditional predicates
+	C *Predicate `json:"c,omitempty"`
+	// Args are the operands of cardinality constraints, which
+	// compare the number of true operands with K
+	Args []*Predicate `json:"args,omitempty"`
+	K    int          `json:"k,omitempty"`
+	// Domain is the name of the domain of a quantifier, when
+	// its elements aren't in Args
+	Domain string `json:"domain,omitempty"`
+	AltRef int    `json:"-"`
 }
 
 const (
@@ -43,14 +56,58 @@ const (
 	NotEquivalesOp = "≢" // C-k ne (custom def. `:digraph ne 8802`)
 	ImpliesOp      = "⇒" // C-k =>
 	FollowsOp      = "⇐" // C-k <=
+	XorOp          = "⊕" // C-k 0+
+	NandOp         = "↑" // C-k -!
+	NorOp          = "↓" // C-k -v
 	Term           = "term"
+	// LetOp is the operator of let expressions, where String
+	// is the bound name, A its value and B the body
+	LetOp = "let"
+	// IfOp is the operator of conditional predicates, where A
+	// is the condition, B the then branch and C the else branch
+	IfOp = "if"
+	// the cardinality constraints are true when the number of
+	// true predicates in Args is at most, at least or exactly K
+	AtMostOp  = "atmost"
+	AtLeastOp = "atleast"
+	ExactlyOp = "exactly"
+	// AtomOp is the operator of parameterized atoms, where String
+	// is the name and Args the parameters
+	AtomOp = "atom"
+	// the quantifiers bind String to each element of a finite
+	// domain in the body B
+	ForallOp = "∀" // C-k FA
+	ExistsOp = "∃" // C-k TE
+	// the comparisons relate the values of A and B, while the
+	// membership InOp relates the value of A with the ones in
+	// Args
+	EqOp = "="
+	NeOp = "≠" // C-k !=
+	LtOp = "<"
+	LeOp = "≤" // C-k =<
+	GtOp = ">"
+	GeOp = "≥" // C-k >=
+	// the operands of comparisons are variables, numbers and
+	// strings, with the name, the number or the unquoted string
+	// in String
+	VarOp    = "var"
+	NumberOp = "num"
+	StringOp = "str"
 )
 
 type NameBool func(string) (bool, bool)
 
 func Reduce(p *Predicate, interp NameBool) (r *Predicate) {
+	r = ReduceEnv(p, interp)
+	return
+}
+
+// ReduceEnv reduces p like Reduce, evaluating the comparisons
+// whose variables have values in env and leaving the rest
+// symbolic
+func ReduceEnv(p *Predicate, env Env) (r *Predicate) {
 	r = new(Predicate)
-	fps := []func(*Predicate, *Predicate, NameBool) bool{
+	fps := []func(*Predicate, *Predicate, Env) bool{
 		reduceNot,
 		reduceAnd,
 		reduceOr,
@@ -59,6 +116,24 @@ func Reduce(p *Predicate, interp NameBool) (r *Predicate) {
 		reduceEquivales,
 		reduceNotEquivales,
 		reduceTerm,
+		reduceLet,
+		reduceXor,
+		reduceNand,
+		reduceNor,
+		reduceIf,
+		reduceCard,
+		reduceCard,
+		reduceCard,
+		reduceAtom,
+		reduceQuant,
+		reduceQuant,
+		reduceCompare,
+		reduceCompare,
+		reduceCompare,
+		reduceCompare,
+		reduceCompare,
+		reduceCompare,
+		reduceMember,
 	}
 	ops := []string{
 		NotOp,
@@ -69,18 +144,39 @@ func Reduce(p *Predicate, interp NameBool) (r *Predicate) {
 		EquivalesOp,
 		NotEquivalesOp,
 		Term,
+		LetOp,
+		XorOp,
+		NandOp,
+		NorOp,
+		IfOp,
+		AtMostOp,
+		AtLeastOp,
+		ExactlyOp,
+		AtomOp,
+		ForallOp,
+		ExistsOp,
+		EqOp,
+		NeOp,
+		LtOp,
+		LeOp,
+		GtOp,
+		GeOp,
+		InOp,
 	}
 	fs := make([]alg.KFunc, len(fps))
 	inf := func(i int) {
-		fs[i] = alg.KFunc{ops[i], func() { fps[i](p, r, interp) }}
+		fs[i] = alg.KFunc{
+			Key:  ops[i],
+			Func: func() { fps[i](p, r, env) },
+		}
 	}
 	alg.Forall(inf, len(fs))
 	alg.ExecF(fs, p.Operator)
 	return
 }
 
-func reduceTerm(p, r *Predicate, itp NameBool) (ok bool) {
-	v, ok := itp(p.String)
+func reduceTerm(p, r *Predicate, itp Env) (ok bool) {
+	v, ok := itp.Bool(p.String)
 	if ok {
 		if v {
 			tr := True()
@@ -95,11 +191,37 @@ func reduceTerm(p, r *Predicate, itp NameBool) (ok bool) {
 	return
 }
 
-func reduceNot(p, r *Predicate, itp NameBool) (ok bool) {
-	nr := Reduce(p.B, itp)
+func reduceLet(p, r *Predicate, itp Env) (ok bool) {
+	x, _ := Expand(p, nil)
+	*r = *ReduceEnv(x, itp)
+	return
+}
+
+func reduceAtom(p, r *Predicate, itp Env) (ok bool) {
+	// an atom is a variable named by its string
+	ok = reduceTerm(NewTerm(String(p)), r, itp)
+	if !ok {
+		*r = *p
+	}
+	return
+}
+
+func reduceQuant(p, r *Predicate, itp Env) (ok bool) {
+	// only quantifiers over explicit elements are grounded
+	x, e := Ground(p, nil)
+	if e == nil {
+		*r = *ReduceEnv(x, itp)
+	} else {
+		*r = *p
+	}
+	return
+}
+
+func reduceNot(p, r *Predicate, itp Env) (ok bool) {
+	nr := ReduceEnv(p.B, itp)
 	v, ok := false, nr.Operator == Term
 	if ok {
-		v, ok = itp(nr.String)
+		v, ok = itp.Bool(nr.String)
 	}
 	if ok {
 		r.String = fmt.Sprint(!v)
@@ -111,29 +233,29 @@ func reduceNot(p, r *Predicate, itp NameBool) (ok bool) {
 	return
 }
 
-func reduceAnd(p, r *Predicate, itp NameBool) (ok bool) {
+func reduceAnd(p, r *Predicate, itp Env) (ok bool) {
 	ok = reduceUnit(p, r, true, itp)
 	return
 }
 
-func reduceOr(p, r *Predicate, itp NameBool) (ok bool) {
+func reduceOr(p, r *Predicate, itp Env) (ok bool) {
 	ok = reduceUnit(p, r, false, itp)
 	return
 }
 
 func reduceUnit(p, r *Predicate, unit bool,
-	itp NameBool) (ok bool) {
+	itp Env) (ok bool) {
 	ps0 := make([]*Predicate, 2)
 	var pr *Predicate
 	ps := []func(){
-		func() { pr = Reduce(p.A, itp); ps0[0] = pr },
-		func() { pr = Reduce(p.B, itp); ps0[1] = pr },
+		func() { pr = ReduceEnv(p.A, itp); ps0[0] = pr },
+		func() { pr = ReduceEnv(p.B, itp); ps0[1] = pr },
 	}
 	unitF, un := false, 0
 	ib := func(i int) (b bool) {
 		ps[i]() // this avoids superflous
 		// evaluation if zero found
-		v, ok := itp(pr.String)
+		v, ok := itp.Bool(pr.String)
 		b = pr.Operator == Term && ok && v != unit
 		if pr.Operator == Term && ok && v == unit {
 			unitF, un = true, i
@@ -159,8 +281,8 @@ func reduceUnit(p, r *Predicate, unit bool,
 	return
 }
 
-func reduceEquivales(p, r *Predicate, itp NameBool) (ok bool) {
-	ps := []*Predicate{Reduce(p.A, itp), Reduce(p.B, itp)}
+func reduceEquivales(p, r *Predicate, itp Env) (ok bool) {
+	ps := []*Predicate{ReduceEnv(p.A, itp), ReduceEnv(p.B, itp)}
 	// A ≡ true ≡ A
 	// A ≡ false ≡ ¬A
 	ib := func(i int) (b bool) {
@@ -200,8 +322,8 @@ func negate(p *Predicate) (r *Predicate) {
 	return
 }
 
-func reduceImplies(p, r *Predicate, itp NameBool) (ok bool) {
-	ps := []*Predicate{Reduce(p.A, itp), Reduce(p.B, itp)}
+func reduceImplies(p, r *Predicate, itp Env) (ok bool) {
+	ps := []*Predicate{ReduceEnv(p.A, itp), ReduceEnv(p.B, itp)}
 	ib := func(i int) (b bool) {
 		b = ps[i].String == TrueStr || ps[i].String == FalseStr
 		return
@@ -223,7 +345,7 @@ func reduceImplies(p, r *Predicate, itp NameBool) (ok bool) {
 	return
 }
 
-func reduceFollows(p, r *Predicate, itp NameBool) (ok bool) {
+func reduceFollows(p, r *Predicate, itp Env) (ok bool) {
 	// b ⇐ a ≡ a ⇒ b
 	np := &Predicate{Operator: ImpliesOp, A: p.B, B: p.A}
 	ok = reduceImplies(np, r, itp)
@@ -234,13 +356,146 @@ func reduceFollows(p, r *Predicate, itp NameBool) (ok bool) {
 	return
 }
 
-func reduceNotEquivales(p, r *Predicate, itp NameBool) (ok bool) {
+func reduceNotEquivales(p, r *Predicate, itp Env) (ok bool) {
 	// a ≢ b ≡ a ≡ ¬b"
-	np := &Predicate{Operator: EquivalesOp, A: p.A, B: negate(p.B)}
+	np := &Predicate{Operator: EquivalesOp, A: p.A, B: complement(p.B)}
 	ok = reduceEquivales(np, r, itp)
 	if !ok {
 		r.Operator = NotEquivalesOp
-		r.B = r.B.A
+		r.B = complement(r.B)
+	}
+	return
+}
+
+// complement returns the negation of p, removing its negation
+// operator if it has one
+func complement(p *Predicate) (r *Predicate) {
+	if p.Operator == NotOp {
+		r = p.B
+	} else {
+		r = negate(p)
+	}
+	return
+}
+
+func reduceXor(p, r *Predicate, itp Env) (ok bool) {
+	// a ⊕ b ≡ a ≢ b
+	np := &Predicate{Operator: NotEquivalesOp, A: p.A, B: p.B}
+	ok = reduceNotEquivales(np, r, itp)
+	if !ok {
+		r.Operator = XorOp
+	}
+	return
+}
+
+func reduceNand(p, r *Predicate, itp Env) (ok bool) {
+	// a ↑ b ≡ ¬(a ∧ b)
+	ok = reduceNegUnit(p, r, AndOp, itp)
+	return
+}
+
+func reduceNor(p, r *Predicate, itp Env) (ok bool) {
+	// a ↓ b ≡ ¬(a ∨ b)
+	ok = reduceNegUnit(p, r, OrOp, itp)
+	return
+}
+
+// reduceNegUnit reduces p, which is the negation of a
+// junction with operator op
+func reduceNegUnit(p, r *Predicate, op string,
+	itp Env) (ok bool) {
+	j := &Predicate{Operator: op, A: p.A, B: p.B}
+	rj := new(Predicate)
+	reduceUnit(j, rj, op == AndOp, itp)
+	ok = rj.Operator != op
+	if ok {
+		*r = *negate(rj)
+		if r.Operator == NotOp && r.B.Operator == NotOp {
+			// ¬¬a ≡ a
+			*r = *r.B.B
+		}
+	} else {
+		r.Operator, r.A, r.B = p.Operator, rj.A, rj.B
+	}
+	return
+}
+
+func reduceIf(p, r *Predicate, itp Env) (ok bool) {
+	c := ReduceEnv(p.A, itp)
+	ok = c.String == TrueStr || c.String == FalseStr
+	if ok {
+		// if true then a else b ≡ a
+		// if false then a else b ≡ b
+		br := p.B
+		if c.String == FalseStr {
+			br = p.C
+		}
+		*r = *ReduceEnv(br, itp)
+	} else {
+		a, b := ReduceEnv(p.B, itp), ReduceEnv(p.C, itp)
+		sa, sb := String(a), String(b)
+		ok = true
+		if sa == sb {
+			// if c then a else a ≡ a
+			*r = *a
+		} else if sa == TrueStr && sb == FalseStr {
+			*r = *c
+		} else if sa == FalseStr && sb == TrueStr {
+			*r = *negate(c)
+		} else {
+			r.Operator, r.A, r.B, r.C = IfOp, c, a, b
+			ok = false
+		}
+	}
+	return
+}
+
+func reduceCard(p, r *Predicate, itp Env) (ok bool) {
+	// t is the amount of true operands and rest has the ones
+	// that weren't reduced to a constant
+	t, rest := 0, make([]*Predicate, 0, len(p.Args))
+	for _, a := range p.Args {
+		ra := ReduceEnv(a, itp)
+		if ra.String == TrueStr {
+			t = t + 1
+		} else if ra.String != FalseStr {
+			rest = append(rest, ra)
+		}
+	}
+	k, u := p.K-t, len(rest)
+	// the constraint holds if the amount of true operands is
+	// in [lo, hi], where the unknown ones can contribute
+	// between 0 and u
+	var lo, hi int
+	if p.Operator == AtMostOp {
+		lo, hi = 0, k
+	} else if p.Operator == AtLeastOp {
+		lo, hi = k, u
+	} else {
+		lo, hi = k, k
+	}
+	ok = true
+	if hi < 0 || lo > u || hi < lo {
+		*r = *False()
+	} else if lo <= 0 && hi >= u {
+		*r = *True()
+	} else if lo == u || hi == 0 {
+		// all the unknown operands must be true, or all false
+		var q *Predicate
+		for _, a := range rest {
+			if hi == 0 {
+				a = negate(a)
+			}
+			if q == nil {
+				q = a
+			} else {
+				q = &Predicate{Operator: AndOp, A: q, B: a}
+			}
+		}
+		*r = *q
+	} else {
+		r.Operator, r.Args, r.K = p.Operator, rest, k
+		ok = false
 	}
 	return
 }
@@ -269,49 +524,173 @@ func NewTerm(s string) (p *Predicate) {
 }
 
 func String(p *Predicate) (r string) {
+	r = str(p, nil)
+	return
+}
+
+// str returns the string of p like String, passing the string
+// of each subtree q through wrap when it isn't nil
+func str(p *Predicate, wrap func(q *Predicate, s string) string) (r string) {
+	sub := func(q *Predicate) string { return str(q, wrap) }
 	if p.Operator == Term {
-		r = p.String
+		r = quoteName(p.String)
 	} else if p.Operator == NotOp {
 		if p.B == nil {
 			panic("Malformed ¬ predicate:" + p.String)
 		}
 		var sfm string
-		if p.B.Operator == Term {
+		if atomic(p.B.Operator) {
 			sfm = "%s"
 		} else {
 			sfm = "(%s)"
 		}
-		r = fmt.Sprintf("%s"+sfm, NotOp, String(p.B))
+		r = fmt.Sprintf("%s"+sfm, NotOp, sub(p.B))
+	} else if p.Operator == LetOp {
+		r = fmt.Sprintf("%s %s %s %s %s %s", LetOp, quoteName(p.String),
+			AssignOp, sub(p.A), InKw, sub(p.B))
+	} else if p.Operator == IfOp {
+		r = fmt.Sprintf("%s %s %s %s %s %s", IfOp, sub(p.A), ThenKw,
+			sub(p.B), ElseKw, sub(p.C))
+	} else if isCard(p.Operator) {
+		r = fmt.Sprintf("%s(%d%s %s)", p.Operator, p.K, Semicolon,
+			joinArgs(p.Args, sub))
+	} else if p.Operator == AtomOp {
+		r = fmt.Sprintf("%s(%s)", quoteName(p.String),
+			joinArgs(p.Args, sub))
+	} else if isQuant(p.Operator) {
+		r = fmt.Sprintf("%s %s %s %s %s %s", p.Operator,
+			quoteName(p.String), InOp, domainString(p), Colon, sub(p.B))
+	} else if isValue(p.Operator) {
+		r = valueString(p)
+	} else if p.Operator == InOp {
+		r = fmt.Sprintf("%s %s %s%s%s", sub(p.A), InOp, OBrace,
+			joinArgs(p.Args, sub), CBrace)
+	} else if isComparison(p.Operator) {
+		r = fmt.Sprintf("%s %s %s", sub(p.A), p.Operator, sub(p.B))
 	} else {
 		r = fmt.Sprintf(
-			format(p.Operator, p.A.Operator)+" %s "+
+			leftFormat(p.Operator, p.A.Operator)+" %s "+
 				format(p.Operator, p.B.Operator),
-			String(p.A), p.Operator, String(p.B))
+			sub(p.A), p.Operator, sub(p.B))
+	}
+	if wrap != nil {
+		r = wrap(p, r)
+	}
+	return
+}
+
+// key returns a string that identifies the tree of p, unlike
+// String, which gives the same for trees that only differ in
+// how they associate
+func key(p *Predicate) (r string) {
+	if p != nil {
+		r = fmt.Sprintf("(%s %q %q %d %s %s %s [%s])", p.Operator,
+			p.String, p.Domain, p.K, key(p.A), key(p.B), key(p.C),
+			joinArgs(p.Args, key))
+	}
+	return
+}
+
+// joinArgs returns the strings of ps, as returned by str,
+// separated by commas
+func joinArgs(ps []*Predicate, str func(*Predicate) string) (r string) {
+	ss := make([]string, len(ps))
+	for i, p := range ps {
+		ss[i] = str(p)
+	}
+	r = strings.Join(ss, Comma+" ")
+	return
+}
+
+// domainString returns the domain name of the quantifier p, or
+// its elements between braces
+func domainString(p *Predicate) (r string) {
+	if p.Domain != "" {
+		r = quoteName(p.Domain)
+	} else {
+		r = OBrace + joinArgs(p.Args, String) + CBrace
+	}
+	return
+}
+
+// quoteName returns name between backquotes when it isn't an
+// identifier of the default syntax or it's a keyword, so the
+// parser reads it back as the same name
+func quoteName(name string) (r string) {
+	plain := name != "" && !isKeyword(name)
+	for i, rn := range name {
+		plain = plain && (unicode.IsLetter(rn) || rn == '_' ||
+			(i != 0 && (unicode.IsDigit(rn) || rn == '.' || rn == '\'')))
+	}
+	if plain {
+		r = name
+	} else {
+		q := strconv.Quote(name)
+		r = "`" + strings.NewReplacer(`\"`, `"`, "`", "\\`").
+			Replace(q[1:len(q)-1]) + "`"
 	}
 	return
 }
 
 func format(oa, ob string) (r string) {
-	priority := map[string]int{
+	pa, pb := priority(oa), priority(ob)
+	mixed := oa != ob || oa == NandOp || oa == NorOp
+	if pa <= pb && !(pa == pb && (pa == 1 || pa == 2) && mixed) {
+		// the second conjunct is for excluding the case
+		// when the pair contains ∧,∨,↑,↓ or ⇒,⇐ which need
+		// parenthesis if appear in sequence since they aren't
+		// associative, like ≡,≢,⊕. Also ↑ and ↓ need them
+		// when they appear in sequence with themselves
+		r = "%s"
+	} else {
+		r = "(%s)"
+	}
+	return
+}
+
+// leftFormat is format for the left operand, which also needs
+// parenthesis when it's the same ⇒ or ⇐ of its parent, since
+// the parser associates them to the right
+func leftFormat(oa, ob string) (r string) {
+	r = format(oa, ob)
+	if oa == ob && (oa == ImpliesOp || oa == FollowsOp) {
+		r = "(%s)"
+	}
+	return
+}
+
+func priority(op string) (r int) {
+	r = map[string]int{
 		Term:           3,
 		NotOp:          3,
+		AtMostOp:       3,
+		AtLeastOp:      3,
+		ExactlyOp:      3,
+		AtomOp:         3,
+		EqOp:           3,
+		NeOp:           3,
+		LtOp:           3,
+		LeOp:           3,
+		GtOp:           3,
+		GeOp:           3,
+		InOp:           3,
+		VarOp:          3,
+		NumberOp:       3,
+		StringOp:       3,
 		AndOp:          2,
 		OrOp:           2,
+		NandOp:         2,
+		NorOp:          2,
 		ImpliesOp:      1,
 		FollowsOp:      1,
 		EquivalesOp:    0,
 		NotEquivalesOp: 0,
-	}
-	pa, pb := priority[oa], priority[ob]
-	if pa <= pb && !(pa == pb && (pa == 1 || pa == 2) && oa != ob) {
-		// the second conjunct is for excluding the case
-		// when the pair contains ∧,∨ or ⇒,⇐ which need
-		// parenthesis if appear in sequence since they aren't
-		// associative, like ≡,≢
-		r = "%s"
-	} else {
-		r = "(%s)"
-	}
+		XorOp:          0,
+		LetOp:          -1,
+		IfOp:           -1,
+		ForallOp:       -1,
+		ExistsOp:       -1,
+	}[op]
 	return
 }
 
@@ -321,14 +700,133 @@ func (p *Predicate) Valid() (ok bool) {
 		ok = ok && p.B.Valid()
 	} else if p.Operator == Term {
 		ok = p.String != ""
+	} else if p.Operator == LetOp {
+		ok = p.String != "" && p.A != nil && p.B != nil
+		ok = ok && p.A.Valid() && p.B.Valid()
+	} else if p.Operator == IfOp {
+		ok = p.A != nil && p.B != nil && p.C != nil && p.String == ""
+		ok = ok && p.A.Valid() && p.B.Valid() && p.C.Valid()
+	} else if isCard(p.Operator) {
+		ok = p.A == nil && p.B == nil && p.C == nil && p.String == "" &&
+			len(p.Args) != 0 && p.K >= 0
+		for i := 0; ok && i != len(p.Args); i++ {
+			ok = p.Args[i].Valid()
+		}
+	} else if p.Operator == AtomOp {
+		ok = p.String != "" && len(p.Args) != 0 && p.A == nil &&
+			p.B == nil
+		for i := 0; ok && i != len(p.Args); i++ {
+			ok = p.Args[i].Operator == Term && p.Args[i].Valid()
+		}
+	} else if p.Operator == InOp {
+		ok = validValue(p.A) && p.B == nil && p.Args != nil &&
+			p.String == ""
+		for i := 0; ok && i != len(p.Args); i++ {
+			ok = p.Args[i].Operator != VarOp && validValue(p.Args[i])
+		}
+	} else if isComparison(p.Operator) {
+		ok = validValue(p.A) && validValue(p.B) && p.String == ""
+	} else if isQuant(p.Operator) {
+		ok = p.String != "" && p.A == nil && p.B != nil && p.B.Valid() &&
+			(p.Domain == "") != (p.Args == nil)
+		for i := 0; ok && i != len(p.Args); i++ {
+			ok = p.Args[i].Operator == Term && p.Args[i].Valid()
+		}
 	} else {
 		ops := []string{AndOp, OrOp, ImpliesOp, EquivalesOp,
-			NotEquivalesOp, FollowsOp}
+			NotEquivalesOp, FollowsOp, XorOp, NandOp, NorOp}
 		ib := func(i int) bool { return p.Operator == ops[i] }
 		ok, _ = alg.BLnSrch(ib, len(ops))
-		ok = ok && p.A != nil && p.B != nil
+		ok = ok && p.A != nil && p.B != nil && p.C == nil
 		ok = ok && p.A.Valid() && p.B.Valid()
 		ok = ok && p.String == ""
 	}
 	return
 }
+
+// Vars returns the sorted names of the free terms in p,
+// excluding the constants true and false. Atoms are named by
+// their strings, and those with quantified parameters are
+// excluded
+func Vars(p *Predicate) (vs []string) {
+	seen := make(map[string]bool)
+	var walk func(*Predicate, map[string]bool)
+	walk = func(q *Predicate, bound map[string]bool) {
+		if q == nil {
+			return
+		}
+		if q.Operator == Term {
+			isConst := q.String == TrueStr || q.String == FalseStr
+			if !isConst && !bound[q.String] && !seen[q.String] {
+				seen[q.String] = true
+				vs = append(vs, q.String)
+			}
+		} else if q.Operator == AtomOp {
+			free := true
+			for _, a := range q.Args {
+				free = free && !bound[a.String]
+			}
+			name := String(q)
+			if free && !seen[name] {
+				seen[name] = true
+				vs = append(vs, name)
+			}
+		} else if q.Operator == LetOp || isQuant(q.Operator) {
+			walk(q.A, bound)
+			nb := map[string]bool{q.String: true}
+			for k := range bound {
+				nb[k] = true
+			}
+			walk(q.B, nb)
+		} else {
+			for _, c := range children(q) {
+				walk(c, bound)
+			}
+		}
+	}
+	walk(p, nil)
+	sort.Strings(vs)
+	return
+}
+
+// children returns the operands of p. The parameters of atoms,
+// the elements of quantifier domains and the values compared
+// by comparisons aren't operands
+func children(p *Predicate) (cs []*Predicate) {
+	for _, c := range []*Predicate{p.A, p.B, p.C} {
+		if c != nil && !isComparison(p.Operator) {
+			cs = append(cs, c)
+		}
+	}
+	if isCard(p.Operator) {
+		cs = append(cs, p.Args...)
+	}
+	return
+}
+
+// hasOperator returns whether some operator in p satisfies f
+func hasOperator(p *Predicate, f func(string) bool) (ok bool) {
+	ok = f(p.Operator)
+	cs := children(p)
+	for i := 0; !ok && i != len(cs); i++ {
+		ok = hasOperator(cs[i], f)
+	}
+	return
+}
+
+func isCard(op string) (ok bool) {
+	ok = op == AtMostOp || op == AtLeastOp || op == ExactlyOp
+	return
+}
+
+func isQuant(op string) (ok bool) {
+	ok = op == ForallOp || op == ExistsOp
+	return
+}
+
+// atomic returns whether predicates with operator op are
+// printed without parenthesis after ¬
+func atomic(op string) (ok bool) {
+	ok = op == Term || op == AtomOp || isCard(op)
+	return
+}
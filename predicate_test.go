@@ -24,6 +24,8 @@ import (
 	"encoding/json"
 	alg "github.com/lamg/algorithms"
 	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -214,7 +216,7 @@ func TestNot(t *testing.T) {
 		B:        &Predicate{Operator: NotOp, B: NewTerm("A")},
 	}
 	nr := new(Predicate)
-	reduceNot(p, nr, itp)
+	reduceNot(p, nr, NameBool(itp))
 	require.Equal(t, String(p), String(nr))
 }
 
@@ -310,3 +312,166 @@ func TestMarshal(t *testing.T) {
 	}
 	alg.Forall(inf, len(ps))
 }
+
+func TestReduceExtended(t *testing.T) {
+	ps := [][]string{
+		{"A ≢ B", "A ≢ B"},
+		{"A ≢ ¬B", "A ≢ ¬B"},
+		{"A ⊕ A", "false"},
+		{"A ⊕ ¬A", "true"},
+		{"A ⊕ false", "A"},
+		{"true ⊕ A", "¬A"},
+		{"A ⊕ B", "A ⊕ B"},
+		{"A ↑ false", "true"},
+		{"A ↑ true", "¬A"},
+		{"A ↑ A", "¬A"},
+		{"(X ∧ Y) ↑ true", "X ↑ Y"},
+		{"A ↑ ¬B", "A ↑ ¬B"},
+		{"A ↓ true", "false"},
+		{"false ↓ A", "¬A"},
+		{"¬A ↓ false", "A"},
+		{"A ↓ B", "A ↓ B"},
+		{"if true then A else B", "A"},
+		{"if false then A else B", "B"},
+		{"if C then A else A", "A"},
+		{"if C then true else false", "C"},
+		{"if C then false else true", "¬C"},
+		{"if C ∧ true then A ∨ false else B", "if C then A else B"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i][0]))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid(), "At %d", i)
+		r := Reduce(p, constInterp)
+		require.True(t, r.Valid(), "At %d", i)
+		require.Equal(t, ps[i][1], String(r), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestStringExtended(t *testing.T) {
+	ps := []struct {
+		pred string
+		e    error
+	}{
+		{"A ⊕ B ≡ C ≢ D", nil},
+		{"A ↑ B", nil},
+		{"(A ↑ B) ↑ C", nil},
+		{"A ↓ (B ↓ C)", nil},
+		{"(A ⇒ B) ⇒ C", nil},
+		{"(A ⇐ B) ⇐ C", nil},
+		{"A ↑ B ↑ C", &NotRecognizedErr{
+			String: NandOp, Expecting: []string{ImpliesOp, FollowsOp,
+				EquivalesOp, NotEquivalesOp, XorOp}}},
+		{"A ∧ (B ↑ C)", nil},
+		{"if A ∧ B then C else D ⊕ E", nil},
+		{"(if A then B else C) ∧ D", nil},
+		{"¬(if A then B else C)", nil},
+		{"if A then B else C ∨ D ∧ E", &NotRecognizedErr{
+			String: AndOp, Expecting: []string{OrOp, ImpliesOp, FollowsOp,
+				EquivalesOp, NotEquivalesOp, XorOp}}},
+		{"(if A then B else C ∨ D) ∧ E", nil},
+		{"if A then B", &NotRecognizedErr{
+			String: string(eof), Expecting: []string{ElseKw}}},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.Equal(t, ps[i].e, e, "At %d", i)
+		if e == nil {
+			require.True(t, p.Valid(), "At %d", i)
+			require.Equal(t, ps[i].pred, String(p), "At %d", i)
+		}
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	prs := []*Printer{{}, {Width: 20}}
+	vs := []string{"A", "B", "C"}
+	inf := func(i int) {
+		p := randTree(rd, vs, 4)
+		ss := []string{String(p)}
+		for _, pr := range prs {
+			ss = append(ss, pr.String(p))
+		}
+		for _, s := range ss {
+			q, e := Parse(strings.NewReader(s))
+			require.NoError(t, e, "At %d: %s", i, s)
+			for j := 0; j != 1<<len(vs); j++ {
+				env := MapEnv{}
+				for k, v := range vs {
+					env[v] = j&(1<<k) != 0
+				}
+				require.Equal(t, Eval(p, env, Kleene), Eval(q, env, Kleene),
+					"At %d: %s", i, s)
+			}
+		}
+	}
+	alg.Forall(inf, 2000)
+}
+
+// randTree returns a random predicate with the names in vs and
+// at most the depth d
+func randTree(rd *rand.Rand, vs []string, d int) (r *Predicate) {
+	ops := []string{AndOp, OrOp, ImpliesOp, FollowsOp, EquivalesOp,
+		NotEquivalesOp, XorOp, NandOp, NorOp}
+	n := rd.Intn(len(ops) + 2)
+	if d == 0 || n == len(ops)+1 {
+		r = NewTerm(vs[rd.Intn(len(vs))])
+	} else if n == len(ops) {
+		r = &Predicate{Operator: NotOp, B: randTree(rd, vs, d-1)}
+	} else {
+		r = &Predicate{Operator: ops[n], A: randTree(rd, vs, d-1),
+			B: randTree(rd, vs, d-1)}
+	}
+	return
+}
+
+func TestCardinality(t *testing.T) {
+	ps := [][]string{
+		{"atmost(1; A, B, C)", "atmost(1; A, B, C)"},
+		{"atmost(1; A, true, B)", "¬A ∧ ¬B"},
+		{"atmost(2; A, true, B)", "atmost(1; A, B)"},
+		{"atmost(1; true, true, A)", "false"},
+		{"atmost(3; A, B, C)", "true"},
+		{"atleast(2; A, false, B)", "A ∧ B"},
+		{"atleast(1; A, false)", "A"},
+		{"atleast(0; A, B)", "true"},
+		{"atleast(3; A, B)", "false"},
+		{"exactly(1; A, true)", "¬A"},
+		{"exactly(1; A, false)", "A"},
+		{"exactly(1; A ∧ true, B, C)", "exactly(1; A, B, C)"},
+		{"exactly(2; true, true, A, B)", "¬A ∧ ¬B"},
+		{"¬exactly(1; A, B) ∨ C", "¬exactly(1; A, B) ∨ C"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i][0]))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid(), "At %d", i)
+		require.Equal(t, ps[i][0], String(p), "At %d", i)
+		r := Reduce(p, constInterp)
+		require.True(t, r.Valid(), "At %d", i)
+		require.Equal(t, ps[i][1], String(r), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	errs := []struct {
+		pred string
+		e    error
+	}{
+		{"atmost(A; B)", &NotRecognizedErr{
+			String: "A", Expecting: []string{Number}}},
+		{"atmost(1, A)", &NotRecognizedErr{
+			String: Comma, Expecting: []string{Semicolon}}},
+		{"atmost 1; A", &NotRecognizedErr{
+			String: "1", Expecting: []string{OPar}}},
+		{"exactly(1; A B)", &NotRecognizedErr{
+			String: "B", Expecting: []string{Comma, CPar}}},
+	}
+	inf = func(i int) {
+		_, e := Parse(strings.NewReader(errs[i].pred))
+		require.Equal(t, errs[i].e, e, "At %d", i)
+	}
+	alg.Forall(inf, len(errs))
+}
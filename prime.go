@@ -0,0 +1,182 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"sort"
+)
+
+// PrimeImplicants returns the prime implicants of p, which are
+// the conjunctions of literals that imply p and stop doing it
+// when any literal is removed. They are ordered by size, and
+// by their variables in alphabetical order, and there are at
+// most limit of them, unless limit is zero. With a limit, they
+// are computed by increasing size until there are enough of
+// them. When p is a tautology the
+// only one is true, and there's none when p is unsatisfiable.
+// p must be propositional, as described in UnsupportedErr
+func PrimeImplicants(p *Predicate, limit int) (ps []*Predicate,
+	e error) {
+	ps, e = primes(p, limit, true)
+	return
+}
+
+// PrimeImplicates returns the prime implicates of p, which are
+// the disjunctions of literals implied by p that stop being
+// implied when any literal is removed. They are ordered and
+// limited like the prime implicants. When p is unsatisfiable
+// the only one is false, and there's none when p is a
+// tautology
+func PrimeImplicates(p *Predicate, limit int) (ps []*Predicate,
+	e error) {
+	ps, e = primes(p, limit, false)
+	return
+}
+
+// primes returns the prime implicants of p when implicants is
+// true, and otherwise its prime implicates, which are the
+// negations of the prime implicants of ¬p
+func primes(p *Predicate, limit int, implicants bool) (ps []*Predicate,
+	e error) {
+	x, e := propositional(p)
+	var b *BDD
+	if e == nil {
+		b, e = Compile(x)
+	}
+	if e == nil {
+		f := b.root
+		if !implicants {
+			f = b.not(f)
+		}
+		memo := make(map[[2]int][][]int)
+		// without a limit every size is computed at once,
+		// otherwise one size at a time from the smallest
+		n, from, to := len(b.Vars), 0, len(b.Vars)
+		if limit != 0 {
+			to = 0
+		}
+		var cs [][]int
+		for to <= n && (limit == 0 || len(cs) < limit) {
+			var ns [][]int
+			for _, c := range b.primeCubes(f, to, memo) {
+				if len(c) >= from {
+					ns = append(ns, c)
+				}
+			}
+			if !implicants {
+				// the implicates have the negated literals
+				for i, c := range ns {
+					ns[i] = make([]int, len(c))
+					for j := range c {
+						ns[i][j] = -c[j]
+					}
+				}
+			}
+			sort.SliceStable(ns, func(i, j int) bool {
+				return cubeLess(ns[i], ns[j])
+			})
+			cs = append(cs, ns...)
+			from, to = to+1, to+1
+		}
+		if limit != 0 && len(cs) > limit {
+			cs = cs[:limit]
+		}
+		terms := varNodes(x)
+		ps = make([]*Predicate, len(cs))
+		for i, c := range cs {
+			ps[i] = b.junction(c, terms, implicants)
+		}
+	}
+	return
+}
+
+// primeCubes returns the prime implicants of the node f with at
+// most size literals, as sorted literals whose variables are
+// levels plus one. For the variable x tested by f, they are the
+// prime implicants of f[x := false] ∧ f[x := true], and the ones
+// of each cofactor that aren't among them, with x or ¬x added
+func (b *BDD) primeCubes(f, size int,
+	memo map[[2]int][][]int) (cs [][]int) {
+	m, ok := memo[[2]int{f, size}]
+	if f == 0 || (f != 1 && size == 0) {
+		cs = [][]int{}
+	} else if f == 1 {
+		cs = [][]int{{}}
+	} else if ok {
+		cs = m
+	} else {
+		nd := b.nodes[f]
+		both := b.primeCubes(b.ite(nd.lo, nd.hi, 0), size, memo)
+		in := make(map[string]bool)
+		for _, c := range both {
+			in[clausesKey([][]int{c})] = true
+		}
+		cs = append(cs, both...)
+		for _, c := range b.primeCubes(nd.lo, size-1, memo) {
+			if !in[clausesKey([][]int{c})] {
+				cs = append(cs, append([]int{-nd.level - 1}, c...))
+			}
+		}
+		for _, c := range b.primeCubes(nd.hi, size-1, memo) {
+			if !in[clausesKey([][]int{c})] {
+				cs = append(cs, append([]int{nd.level + 1}, c...))
+			}
+		}
+		memo[[2]int{f, size}] = cs
+	}
+	return
+}
+
+// cubeLess orders cubes by size, and then by their literals,
+// where a variable comes before the following ones and ¬x
+// before x
+func cubeLess(a, b []int) (ok bool) {
+	ok = len(a) < len(b)
+	eq := len(a) == len(b)
+	for i := 0; eq && i != len(a); i++ {
+		ok = abs(a[i]) < abs(b[i]) || (abs(a[i]) == abs(b[i]) && a[i] < b[i])
+		eq = a[i] == b[i]
+	}
+	return
+}
+
+// junction returns the conjunction of the literals in c when
+// and is true, otherwise their disjunction
+func (b *BDD) junction(c []int, terms map[string]*Predicate,
+	and bool) (r *Predicate) {
+	op := AndOp
+	if !and {
+		op = OrOp
+	}
+	r = constant(and)
+	for i := len(c) - 1; i >= 0; i-- {
+		l := terms[b.Vars[abs(c[i])-1]]
+		if c[i] < 0 {
+			l = negate(l)
+		}
+		if i == len(c)-1 {
+			r = l
+		} else {
+			r = &Predicate{Operator: op, A: l, B: r}
+		}
+	}
+	return
+}
@@ -0,0 +1,124 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestPrimes(t *testing.T) {
+	ps := []struct {
+		pred                   string
+		implicants, implicates []string
+	}{
+		{"(A ∧ B) ∨ (¬A ∧ C)",
+			[]string{"¬A ∧ C", "A ∧ B", "B ∧ C"},
+			[]string{"¬A ∨ B", "A ∨ C", "B ∨ C"}},
+		{"A ⇒ B", []string{"¬A", "B"}, []string{"¬A ∨ B"}},
+		{"true", []string{"true"}, []string{}},
+		{"A ∧ ¬A", []string{}, []string{"false"}},
+		{"A ≡ B", []string{"¬A ∧ ¬B", "A ∧ B"},
+			[]string{"¬A ∨ B", "A ∨ ¬B"}},
+		{"atleast(2; A, B, C)", []string{"A ∧ B", "A ∧ C", "B ∧ C"},
+			[]string{"A ∨ B", "A ∨ C", "B ∨ C"}},
+		{"∃ x ∈ {a, b} : p(x) ∧ ¬q", []string{"p(a) ∧ ¬q", "p(b) ∧ ¬q"},
+			[]string{"¬q", "p(a) ∨ p(b)"}},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		is, e := PrimeImplicants(p, 0)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].implicants, strs(is), "At %d", i)
+		cs, e := PrimeImplicates(p, 0)
+		require.NoError(t, e, "At %d", i)
+		require.Equal(t, ps[i].implicates, strs(cs), "At %d", i)
+		x, e := Ground(p, nil)
+		require.NoError(t, e)
+		forallAssign(Vars(x), func(m map[string]bool) {
+			v := Reduce(x, assignInterp(m)).String == TrueStr
+			for _, q := range is {
+				// an implicant true makes p true
+				require.True(t, v ||
+					Reduce(q, assignInterp(m)).String != TrueStr, "At %d", i)
+			}
+			for _, q := range cs {
+				require.True(t, !v ||
+					Reduce(q, assignInterp(m)).String == TrueStr, "At %d", i)
+			}
+		})
+	}
+	alg.Forall(inf, len(ps))
+
+	p, e := Parse(strings.NewReader("(A ∧ B) ∨ (¬A ∧ C)"))
+	require.NoError(t, e)
+	is, e := PrimeImplicants(p, 2)
+	require.NoError(t, e)
+	require.Equal(t, []string{"¬A ∧ C", "A ∧ B"}, strs(is))
+	// the parity has 2¹⁷ prime implicants with 18 literals, which
+	// aren't computed when the first one is enough
+	var x *Predicate
+	for i := 0; i != 18; i++ {
+		x = join(x, XorOp, NewTerm(fmt.Sprintf("x%d", i)))
+	}
+	is, e = PrimeImplicants(&Predicate{Operator: OrOp, A: NewTerm("A"),
+		B: x}, 1)
+	require.NoError(t, e)
+	require.Equal(t, []string{"A"}, strs(is))
+	p, e = Parse(strings.NewReader("a < 1"))
+	require.NoError(t, e)
+	_, e = PrimeImplicates(p, 0)
+	require.Equal(t, &UnsupportedErr{Operator: "comparison"}, e)
+}
+
+func TestPrimesLimit(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	inf := func(i int) {
+		// the limited primes are the first ones of the unlimited
+		p := randTree(rd, []string{"A", "B", "C", "D"}, 4)
+		for _, f := range []func(*Predicate, int) ([]*Predicate,
+			error){PrimeImplicants, PrimeImplicates} {
+			all, e := f(p, 0)
+			require.NoError(t, e, "At %d", i)
+			n := 1 + rd.Intn(4)
+			some, e := f(p, n)
+			require.NoError(t, e, "At %d", i)
+			if n > len(all) {
+				n = len(all)
+			}
+			require.Equal(t, strs(all[:n]), strs(some), "At %d", i)
+		}
+	}
+	alg.Forall(inf, 200)
+}
+
+func strs(ps []*Predicate) (ss []string) {
+	ss = make([]string, len(ps))
+	for i, p := range ps {
+		ss[i] = String(p)
+	}
+	return
+}
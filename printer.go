@@ -0,0 +1,381 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Printer writes predicates like String does, with options for
+// the operator symbols, spacing, parenthesis and line width.
+// The zero value prints exactly like String
+type Printer struct {
+	// ASCII makes the printer use the symbols in ASCIIOps
+	ASCII bool
+	// Width is the maximum line width. Longer predicates are
+	// broken at the operators of their outermost chain, with
+	// one operand per line, EWD style:
+	//
+	//      A ∧ B
+	//   ≡  C
+	//
+	// Zero means no limit
+	Width int
+	// Spacing selects which binary operators are surrounded
+	// by spaces
+	Spacing Spacing
+	// Parens makes the printer surround every operand by
+	// parenthesis, instead of only those required by the
+	// operators precedence, except terms, atoms, cardinality
+	// constraints and negations, which can't be split by the
+	// operators around them
+	Parens bool
+	// Comments are printed before or after the operands they
+	// are attached to, like the inline comments of a Statement.
+	// A line comment ends the line where it's printed
+	Comments []Comment
+}
+
+// Spacing is the style of the spaces around binary operators
+type Spacing int
+
+const (
+	// SpaceAll puts one space on each side of every operator
+	SpaceAll Spacing = iota
+	// SpaceNone doesn't put spaces around operators, except
+	// the words of ASCII mode, like xor, which would fuse with
+	// their operands
+	SpaceNone
+	// SpacePriority puts spaces only around the operators that
+	// bind weaker than ∧ and ∨, as in A∧B ≡ C, making the
+	// structure visible with spacing
+	SpacePriority
+)
+
+// ASCIIOps are the symbols used by a Printer in ASCII mode
+var ASCIIOps = map[string]string{
+	NotOp:          "~",
+	AndOp:          `/\`,
+	OrOp:           `\/`,
+	EquivalesOp:    "==",
+	NotEquivalesOp: "=/=",
+	ImpliesOp:      "==>",
+	FollowsOp:      "<==",
+	XorOp:          "xor",
+	NandOp:         "nand",
+	NorOp:          "nor",
+	EqOp:           "=",
+	NeOp:           "!=",
+	LtOp:           "<",
+	LeOp:           "<=",
+	GtOp:           ">",
+	GeOp:           ">=",
+	ForallOp:       "forall",
+	ExistsOp:       "exists",
+	InOp:           "in",
+}
+
+// String returns p printed according to the printer options
+func (pr *Printer) String(p *Predicate) (r string) {
+	ls := pr.operand(p, false, 0)
+	// the lines left empty after line comments are removed,
+	// except the last one, which makes the text end after the
+	// last comment
+	var ks []string
+	for i, l := range ls {
+		if strings.TrimSpace(l) != "" {
+			ks = append(ks, l)
+		} else if i == len(ls)-1 {
+			ks = append(ks, "")
+		}
+	}
+	r = strings.ReplaceAll(strings.Join(ks, "\n"), commentNL, "\n")
+	return
+}
+
+// commentNL replaces the newlines inside block comments while
+// the printed text is split in lines
+const commentNL = "\x00"
+
+// noted surrounds s, the text of q, by the comments attached
+// to q
+func (pr *Printer) noted(q *Predicate, s string) (r string) {
+	pre, post := pr.notes(q)
+	r = pre + s + post
+	return
+}
+
+// notes returns the comments printed before and after q. Line
+// comments end with a newline
+func (pr *Printer) notes(q *Predicate) (pre, post string) {
+	for _, c := range pr.Comments {
+		t := strings.ReplaceAll(c.Text, "\n", commentNL)
+		line := strings.HasPrefix(t, "--") || strings.HasPrefix(t, "#")
+		if c.Before == q && line {
+			pre = pre + t + "\n"
+		} else if c.Before == q {
+			pre = pre + t + " "
+		}
+		if c.After == q && line {
+			post = post + " " + t + "\n"
+		} else if c.After == q {
+			post = post + " " + t
+		}
+	}
+	return
+}
+
+// grouped tells whether q is a binary operator with comments,
+// which are printed outside its parenthesis to keep them
+// attached to q when the text is parsed again
+func (pr *Printer) grouped(q *Predicate) (ok bool) {
+	if q.A != nil && q.B != nil && q.Operator != LetOp &&
+		!isComparison(q.Operator) {
+		pre, post := pr.notes(q)
+		ok = pre+post != ""
+	}
+	return
+}
+
+// Fprint writes p followed by a newline to w
+func (pr *Printer) Fprint(w io.Writer, p *Predicate) (e error) {
+	_, e = io.WriteString(w, pr.String(p)+"\n")
+	return
+}
+
+func (pr *Printer) op(o string) (r string) {
+	r = o
+	if pr.ASCII {
+		r = ASCIIOps[o]
+	}
+	return
+}
+
+func (pr *Printer) sep(o string) (r string) {
+	rn, _ := utf8.DecodeRuneInString(pr.op(o))
+	if pr.Spacing == SpaceAll || unicode.IsLetter(rn) ||
+		(pr.Spacing == SpacePriority && priority(o) < priority(AndOp)) {
+		r = " "
+	}
+	return
+}
+
+func (pr *Printer) paren(parent, child string, left bool) (ok bool) {
+	f := format
+	if left {
+		f = leftFormat
+	}
+	if parent == NotOp {
+		ok = !atomic(child)
+	} else {
+		ok = f(parent, child) != "%s" ||
+			(pr.Parens && child != NotOp && !atomic(child))
+	}
+	return
+}
+
+func (pr *Printer) line(p *Predicate) (r string) {
+	sub := func(q *Predicate) string { return pr.noted(q, pr.line(q)) }
+	child := func(q *Predicate) (s string) {
+		s = pr.line(q)
+		if pr.paren(p.Operator, q.Operator, q == p.A) || pr.grouped(q) {
+			s = "(" + s + ")"
+		}
+		s = pr.noted(q, s)
+		return
+	}
+	if p.Operator == Term {
+		r = quoteName(p.String)
+	} else if p.Operator == NotOp {
+		r = pr.op(NotOp) + child(p.B)
+	} else if p.Operator == LetOp {
+		r = LetOp + " " + quoteName(p.String) + " " + AssignOp + " " + sub(p.A) +
+			" " + InKw + " " + sub(p.B)
+	} else if p.Operator == IfOp {
+		r = IfOp + " " + sub(p.A) + " " + ThenKw + " " + sub(p.B) +
+			" " + ElseKw + " " + sub(p.C)
+	} else if isCard(p.Operator) {
+		r = fmt.Sprintf("%s(%d%s %s)", p.Operator, p.K, Semicolon,
+			joinArgs(p.Args, sub))
+	} else if p.Operator == AtomOp || isValue(p.Operator) {
+		r = String(p)
+	} else if p.Operator == InOp {
+		r = pr.line(p.A) + " " + pr.op(InOp) + " " + OBrace +
+			joinArgs(p.Args, String) + CBrace
+	} else if isQuant(p.Operator) {
+		r = pr.quantHead(p) + " " + sub(p.B)
+	} else if isComparison(p.Operator) {
+		s := pr.sep(p.Operator)
+		r = child(p.A) + s + pr.op(p.Operator) + s + child(p.B)
+	} else {
+		s := pr.sep(p.Operator)
+		r = child(p.A) + s + pr.op(p.Operator) + s + child(p.B)
+	}
+	return
+}
+
+// lines returns p broken in lines that, when possible, fit in
+// the printer width once indented by ind columns. A line
+// comment inside p makes it break
+func (pr *Printer) lines(p *Predicate, ind int) (ls []string) {
+	l := pr.line(p)
+	fits := !strings.Contains(l, "\n") &&
+		(pr.Width == 0 || utf8.RuneCountInString(l)+ind <= pr.Width)
+	if fits || atomic(p.Operator) || isComparison(p.Operator) {
+		ls = []string{l}
+	} else if p.Operator == LetOp {
+		// let x := A
+		// in  B
+		hd := LetOp + " " + quoteName(p.String) + " " + AssignOp + " "
+		ls = indented(hd,
+			pr.operand(p.A, false, ind+utf8.RuneCountInString(hd)))
+		ls = append(ls, indented(InKw+"  ", pr.operand(p.B, false, ind+4))...)
+	} else if p.Operator == IfOp {
+		// if   C
+		// then A
+		// else B
+		parts := []struct {
+			kw string
+			q  *Predicate
+		}{{IfOp + "   ", p.A}, {ThenKw + " ", p.B}, {ElseKw + " ", p.C}}
+		for _, x := range parts {
+			ls = append(ls, indented(x.kw, pr.operand(x.q, false, ind+5))...)
+		}
+	} else if isQuant(p.Operator) {
+		// ∀ x ∈ D :
+		//   B
+		ls = []string{pr.quantHead(p)}
+		ls = append(ls, indented("  ", pr.operand(p.B, false, ind+2))...)
+	} else if p.Operator == NotOp {
+		n := pr.op(NotOp)
+		ls = indented(n, pr.operand(p.B, pr.paren(NotOp, p.B.Operator, false),
+			ind+utf8.RuneCountInString(n)))
+	} else {
+		ops, xs := pr.chain(p)
+		w := 0
+		for _, o := range ops {
+			if n := utf8.RuneCountInString(pr.op(o)); n > w {
+				w = n
+			}
+		}
+		// the operator column plus two spaces
+		w = w + 2
+		for i, x := range xs {
+			var hd string
+			if i == 0 {
+				hd = strings.Repeat(" ", w)
+			} else {
+				o := pr.op(ops[i-1])
+				hd = o + strings.Repeat(" ", w-utf8.RuneCountInString(o))
+			}
+			// a right operand with the operator of p is joined in
+			// the chain, so only left ones can have it here
+			par := pr.paren(p.Operator, x.Operator, true) || pr.grouped(x)
+			xl := pr.operand(x, par, ind+w)
+			ls = append(ls, hd+xl[0])
+			for _, s := range xl[1:] {
+				ls = append(ls, strings.Repeat(" ", w)+s)
+			}
+		}
+	}
+	return
+}
+
+// quantHead returns the quantifier p up to the colon before
+// its body
+func (pr *Printer) quantHead(p *Predicate) (r string) {
+	r = pr.op(p.Operator) + " " + quoteName(p.String) + " " +
+		pr.op(InOp) + " " + domainString(p) + " " + Colon
+	return
+}
+
+// indented prepends hd to the first line and spaces of the
+// same width to the rest
+func indented(hd string, ls []string) (r []string) {
+	pad := strings.Repeat(" ", utf8.RuneCountInString(hd))
+	r = make([]string, len(ls))
+	for i, l := range ls {
+		if i == 0 {
+			r[i] = hd + l
+		} else {
+			r[i] = pad + l
+		}
+	}
+	return
+}
+
+// operand returns the lines of p surrounded by parenthesis if
+// par is true, and by the comments attached to p
+func (pr *Printer) operand(p *Predicate, par bool,
+	ind int) (ls []string) {
+	if par {
+		ls = split(pr.lines(p, ind+1))
+		ls[0] = "(" + ls[0]
+		for i := 1; i != len(ls); i++ {
+			ls[i] = " " + ls[i]
+		}
+		ls[len(ls)-1] = ls[len(ls)-1] + ")"
+	} else {
+		ls = split(pr.lines(p, ind))
+	}
+	pre, post := pr.notes(p)
+	ls[0] = pre + ls[0]
+	ls[len(ls)-1] = ls[len(ls)-1] + post
+	ls = split(ls)
+	return
+}
+
+// split splits the lines in ls containing newlines
+func split(ls []string) (r []string) {
+	for _, l := range ls {
+		r = append(r, strings.Split(l, "\n")...)
+	}
+	return
+}
+
+// chain flattens the operands of p joined by operators that
+// don't need parenthesis between them, and without comments, returning the operators
+// and the operands in the order they are printed
+func (pr *Printer) chain(p *Predicate) (ops []string,
+	xs []*Predicate) {
+	joined := func(q *Predicate) bool {
+		return q.Operator != Term && q.Operator != NotOp &&
+			priority(q.Operator) == priority(p.Operator) &&
+			!pr.paren(p.Operator, q.Operator, q == p.A) && !pr.grouped(q)
+	}
+	if joined(p.A) {
+		ops, xs = pr.chain(p.A)
+	} else {
+		xs = []*Predicate{p.A}
+	}
+	ops = append(ops, p.Operator)
+	if joined(p.B) {
+		bo, bx := pr.chain(p.B)
+		ops, xs = append(ops, bo...), append(xs, bx...)
+	} else {
+		xs = append(xs, p.B)
+	}
+	return
+}
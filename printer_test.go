@@ -0,0 +1,118 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestPrinter(t *testing.T) {
+	ps := []struct {
+		pr   *Printer
+		pred string
+		out  string
+	}{
+		{&Printer{}, "a ≡ b ≢ c ≡ ¬x ∧ (¬z ≡ y) ≢ true",
+			"a ≡ b ≢ c ≡ ¬x ∧ (¬z ≡ y) ≢ true"},
+		{&Printer{ASCII: true}, "¬A ∧ B ≡ C ⇒ D ≢ E",
+			`~A /\ B == C ==> D =/= E`},
+		{&Printer{Spacing: SpaceNone}, "A ∨ B ⇒ C", "A∨B⇒C"},
+		{&Printer{Spacing: SpacePriority}, "A ∨ ¬B ⇒ C ∨ D ≡ E",
+			"A∨¬B ⇒ C∨D ≡ E"},
+		{&Printer{Parens: true}, "A ∨ B ∨ ¬C ≡ D",
+			"(A ∨ (B ∨ ¬C)) ≡ D"},
+		{&Printer{Width: 20}, "alpha ∧ beta ≡ gamma ∨ delta ≡ epsilon",
+			"   alpha ∧ beta\n≡  gamma ∨ delta\n≡  epsilon"},
+		{&Printer{Width: 22}, "alpha ∧ (beta ≡ gamma ∨ delta)",
+			"   alpha\n" +
+				"∧  (   beta\n" +
+				"    ≡  gamma ∨ delta)"},
+		{&Printer{Width: 12, ASCII: true}, "¬(alpha ∧ beta ∧ gamma)",
+			"~(    alpha\n" +
+				"  /\\  beta\n" +
+				"  /\\  gamma)"},
+		{&Printer{ASCII: true}, "A ⊕ B ↑ C", "A xor B nand C"},
+		{&Printer{ASCII: true, Spacing: SpaceNone}, "A ⊕ (B ∧ C) ∨ n ∈ {1}",
+			`A xor (B/\C)\/n in {1}`},
+		{&Printer{ASCII: true, Spacing: SpacePriority}, "A ↓ B ≡ C",
+			"A nor B == C"},
+		{&Printer{Width: 20}, "if alpha then beta ∧ gamma else delta",
+			"if   alpha\nthen beta ∧ gamma\nelse delta"},
+		{&Printer{Width: 22}, "let x := alpha ∨ beta in x ∧ gamma",
+			"let x := alpha ∨ beta\nin  x ∧ gamma"},
+		{&Printer{ASCII: true}, "¬atmost(1; A ∧ B, C)",
+			`~atmost(1; A /\ B, C)`},
+		{&Printer{Width: 10}, "exactly(1; alpha, beta)",
+			"exactly(1; alpha, beta)"},
+		{&Printer{ASCII: true}, "let `a b` := x' in `a b` ∧ `c` ≥ 1",
+			"let `a b` := x' in `a b` /\\ c >= 1"},
+		{&Printer{ASCII: true}, "∀ x ∈ D : p(x) ∧ q",
+			`forall x in D : p(x) /\ q`},
+		{&Printer{Width: 20}, "∃ x ∈ {a, b} : alpha(x) ∨ beta(x)",
+			"∃ x ∈ {a, b} :\n  alpha(x) ∨ beta(x)"},
+		{&Printer{ASCII: true}, `¬(age ≥ 18) ∨ tag ∈ {"a"}`,
+			`~(age >= 18) \/ tag in {"a"}`},
+		{&Printer{Spacing: SpacePriority}, "x ≠ 1 ∧ y ⇒ z",
+			"x≠1∧y ⇒ z"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		require.Equal(t, ps[i].out, ps[i].pr.String(p), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	var sb strings.Builder
+	require.NoError(t, new(Printer).Fprint(&sb, True()))
+	require.Equal(t, "true\n", sb.String())
+}
+
+func TestPrinterComments(t *testing.T) {
+	ps := []struct {
+		pr   *Printer
+		pred string
+		out  string
+	}{
+		{&Printer{}, "A (* a *) ∨ ¬(* b *) B", "A (* a *) ∨ ¬(* b *) B"},
+		{&Printer{}, "A ∧ -- first\nB ∧ C", "   A\n∧  -- first\n   B\n∧  C"},
+		{&Printer{Width: 80}, "(A ∨ B) -- group\n∧ C",
+			"   (A ∨ B) -- group\n∧  C"},
+		{&Printer{Width: 80}, "A ∧ (* two\n\nlines *) B",
+			"A ∧ (* two\n\nlines *) B"},
+		{&Printer{Width: 80}, "∀ x ∈ {a}: -- body\nP(x)",
+			"∀ x ∈ {a} :\n  -- body\n  P(x)"},
+		{&Printer{Width: 80}, "¬(A -- a\n∧ B)", "¬(   A -- a\n  ∧  B)"},
+	}
+	inf := func(i int) {
+		ss := ParseAll(strings.NewReader(ps[i].pred))
+		require.Len(t, ss, 1, "At %d", i)
+		pr := *ps[i].pr
+		pr.Comments = ss[0].Inline
+		require.Equal(t, ps[i].out, pr.String(ss[0].Predicate), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+	p := NewTerm("A")
+	pr := &Printer{Comments: []Comment{{Text: "-- a", After: p}}}
+	require.Equal(t, "A -- a\n", pr.String(p))
+}
@@ -0,0 +1,135 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Estimator computes the probability of predicates, exactly
+// when their BDD is small enough and by sampling otherwise
+type Estimator struct {
+	// MaxNodes is the greatest amount of nodes created while
+	// building the BDD for the exact probability, including the
+	// intermediate ones. Zero means there's no limit
+	MaxNodes int
+	// Samples is the amount of random assignments evaluated
+	// for estimating the probability of larger predicates,
+	// which must be positive for estimating it
+	Samples int
+	// Seed initializes the source of the random assignments
+	Seed int64
+}
+
+// DefaultEstimator is the Estimator used by Probability
+var DefaultEstimator = Estimator{
+	MaxNodes: 1 << 20,
+	Samples:  100000,
+	Seed:     1,
+}
+
+// ProbabilityErr is returned when a variable has a probability
+// outside [0, 1]
+type ProbabilityErr struct {
+	Name  string
+	Value float64
+}
+
+func (r *ProbabilityErr) Error() (s string) {
+	s = fmt.Sprintf("Probability of '%s' is %v, expecting a value in "+
+		"[0, 1]", r.Name, r.Value)
+	return
+}
+
+// SamplesErr is returned when the probability must be estimated
+// by sampling and the amount of samples isn't positive
+type SamplesErr struct {
+	Samples int
+}
+
+func (r *SamplesErr) Error() (s string) {
+	s = fmt.Sprintf("Samples is %d, expecting a positive amount",
+		r.Samples)
+	return
+}
+
+// Probability returns the probability of p being true when its
+// variables are independent, and each one is true with the
+// probability in ps, or 1/2 when it isn't there. It uses
+// DefaultEstimator
+func Probability(p *Predicate, ps map[string]float64) (r float64,
+	e error) {
+	r, _, e = DefaultEstimator.Probability(p, ps)
+	return
+}
+
+// Probability returns the probability of p like the function
+// Probability, and whether it's exact or an estimation by
+// sampling, used when building the BDD of p needs more than
+// s.MaxNodes nodes
+func (s Estimator) Probability(p *Predicate,
+	ps map[string]float64) (r float64, exact bool, e error) {
+	for k, v := range ps {
+		if e == nil && !(v >= 0 && v <= 1) {
+			e = &ProbabilityErr{Name: k, Value: v}
+		}
+	}
+	var b *BDD
+	if e == nil {
+		b, e = compile(p, s.MaxNodes)
+	}
+	if e == nil {
+		r, exact = b.Probability(ps), true
+	} else if _, ok := e.(*LimitErr); ok && s.Samples <= 0 {
+		e = &SamplesErr{Samples: s.Samples}
+	} else if ok {
+		var x *Predicate
+		x, e = propositional(p)
+		if e == nil {
+			r = s.sample(x, ps)
+		}
+	}
+	return
+}
+
+// sample returns the fraction of s.Samples random assignments
+// that satisfy p, which is the estimation of its probability
+func (s Estimator) sample(p *Predicate, ps map[string]float64) (r float64) {
+	rd := rand.New(rand.NewSource(s.Seed))
+	vs := Vars(p)
+	env := MapEnv{}
+	sat := 0
+	for i := 0; i < s.Samples; i++ {
+		for _, v := range vs {
+			q, ok := ps[v]
+			if !ok {
+				q = 0.5
+			}
+			env[v] = rd.Float64() < q
+		}
+		if Eval(p, env, Kleene) == TTrue {
+			sat = sat + 1
+		}
+	}
+	r = float64(sat) / float64(s.Samples)
+	return
+}
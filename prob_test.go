@@ -0,0 +1,79 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestProbability(t *testing.T) {
+	ps := map[string]float64{"A": 0.3, "B": 0.6, "C": 0.9}
+	xs := []struct {
+		pred string
+		prob float64
+	}{
+		{"A", 0.3},
+		{"D", 0.5},
+		{"true", 1},
+		{"A ∧ ¬A", 0},
+		{"A ∧ B", 0.18},
+		{"A ∨ B", 0.72},
+		{"A ≡ B", 0.3*0.6 + 0.7*0.4},
+		{"exactly(1; A, B, C)", 0.3*0.4*0.1 + 0.7*0.6*0.1 + 0.7*0.4*0.9},
+		{"if C then A else B", 0.9*0.3 + 0.1*0.6},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(xs[i].pred))
+		require.NoError(t, e, "At %d", i)
+		r, e := Probability(p, ps)
+		require.NoError(t, e, "At %d", i)
+		require.InDelta(t, xs[i].prob, r, 1e-9, "At %d", i)
+		// the estimation by sampling is close
+		es := Estimator{MaxNodes: 2, Samples: 20000, Seed: 1}
+		r, _, e = es.Probability(p, ps)
+		require.NoError(t, e, "At %d", i)
+		require.InDelta(t, xs[i].prob, r, 0.02, "At %d", i)
+	}
+	alg.Forall(inf, len(xs))
+
+	p, e := Parse(strings.NewReader("A ∧ B ∧ C"))
+	require.NoError(t, e)
+	r, exact, e := Estimator{MaxNodes: 2, Samples: 1000}.Probability(p, ps)
+	require.NoError(t, e)
+	require.False(t, exact)
+	require.InDelta(t, 0.162, r, 0.05)
+	_, exact, e = DefaultEstimator.Probability(p, ps)
+	require.NoError(t, e)
+	require.True(t, exact)
+	_, e = Probability(p, map[string]float64{"A": 1.5})
+	require.Equal(t, &ProbabilityErr{Name: "A", Value: 1.5}, e)
+	for _, n := range []int{0, -1} {
+		_, _, e = Estimator{MaxNodes: 2, Samples: n}.Probability(p, ps)
+		require.Equal(t, &SamplesErr{Samples: n}, e)
+	}
+	r, exact, e = Estimator{Samples: -1}.Probability(p, ps)
+	require.NoError(t, e)
+	require.True(t, exact)
+	require.InDelta(t, 0.162, r, 0.05)
+}
@@ -0,0 +1,170 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Proof is a certificate of a predicate being a tautology,
+// which is a refutation of the CNF of its negation in the DRAT
+// format, with the clauses learned by the solver
+type Proof struct {
+	// CNF has the clauses of the negation of the predicate
+	CNF *CNF
+	// Lemmas has the learned clauses in the order they were
+	// learned, ending with the empty clause. Each one is
+	// implied by unit propagation on the CNF and the previous
+	// lemmas
+	Lemmas [][]int
+}
+
+// ProofErr is returned when a lemma of a proof isn't implied by
+// unit propagation
+type ProofErr struct {
+	// Lemma is the index of the lemma in Lemmas
+	Lemma int
+}
+
+func (p *ProofErr) Error() (s string) {
+	s = fmt.Sprintf("Lemma %d isn't implied by unit propagation",
+		p.Lemma)
+	return
+}
+
+// CounterexampleErr is returned by Prove when the predicate
+// isn't a tautology
+type CounterexampleErr struct {
+	// Model is an assignment that makes the predicate false
+	Model map[string]bool
+}
+
+func (c *CounterexampleErr) Error() (s string) {
+	s = fmt.Sprintf("Not a tautology, false with %s", literals(c.Model))
+	return
+}
+
+// Prove returns a proof of p being a tautology, or a
+// CounterexampleErr with an assignment that makes p false. p must
+// be propositional, as described in UnsupportedErr
+func Prove(p *Predicate) (r *Proof, e error) {
+	c, e := ToCNF(&Predicate{Operator: NotOp, B: p}, SequentialCounter)
+	if e == nil {
+		r = &Proof{CNF: c}
+		s := newCDCL()
+		s.grow(c.NumVars)
+		s.learned = func(cl []int) {
+			r.Lemmas = append(r.Lemmas, append([]int{}, cl...))
+		}
+		for _, cl := range c.Clauses {
+			s.addClause(cl)
+		}
+		ok, _ := s.solve(nil)
+		if ok {
+			m := make(map[string]bool)
+			for i, v := range c.Vars {
+				m[v] = s.value[i+1] == 1
+			}
+			r, e = nil, &CounterexampleErr{Model: m}
+		} else {
+			r.Lemmas = append(r.Lemmas, []int{})
+		}
+	}
+	return
+}
+
+// Check checks that each lemma of r is implied by unit
+// propagation (RUP) on the CNF and the previous lemmas, and
+// that the last one is the empty clause, which makes r a
+// refutation of its CNF. It returns a ProofErr for the first
+// lemma that isn't implied
+func (r *Proof) Check() (e error) {
+	cls := append([][]int{}, r.CNF.Clauses...)
+	for i := 0; e == nil && i != len(r.Lemmas); i++ {
+		if rup(cls, r.Lemmas[i]) {
+			cls = append(cls, r.Lemmas[i])
+		} else {
+			e = &ProofErr{Lemma: i}
+		}
+	}
+	if e == nil && (len(r.Lemmas) == 0 ||
+		len(r.Lemmas[len(r.Lemmas)-1]) != 0) {
+		e = &ProofErr{Lemma: len(r.Lemmas)}
+	}
+	return
+}
+
+// rup returns whether assigning false to the literals of cl
+// and propagating the unit clauses of cls reaches a conflict
+func rup(cls [][]int, cl []int) (ok bool) {
+	// value has 1 for the true literals and -1 for the false
+	// ones
+	value := make(map[int]int)
+	set := func(l int) {
+		ok = ok || value[l] == -1
+		value[l], value[-l] = 1, -1
+	}
+	for _, l := range cl {
+		set(-l)
+	}
+	changed := true
+	for !ok && changed {
+		changed = false
+		for i := 0; !ok && i != len(cls); i++ {
+			// unit is the first literal that isn't false, and
+			// many tells whether there's a different one, since
+			// clauses can repeat literals
+			unit, many, sat := 0, false, false
+			for _, l := range cls[i] {
+				sat = sat || value[l] == 1
+				if value[l] == 0 && unit == 0 {
+					unit = l
+				} else if value[l] == 0 && l != unit {
+					many = true
+				}
+			}
+			if !sat && unit == 0 {
+				ok = true
+			} else if !sat && !many {
+				set(unit)
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+// WriteDRAT writes the lemmas of r in the DRAT format, one
+// clause per line ending with 0, which checkers like drat-trim
+// verify together with its CNF written by WriteDIMACS
+func WriteDRAT(w io.Writer, r *Proof) (e error) {
+	var sb strings.Builder
+	for _, cl := range r.Lemmas {
+		for _, l := range cl {
+			fmt.Fprintf(&sb, "%d ", l)
+		}
+		sb.WriteString("0\n")
+	}
+	_, e = io.WriteString(w, sb.String())
+	return
+}
@@ -0,0 +1,140 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"errors"
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestProve(t *testing.T) {
+	ps := []string{
+		"A ∨ ¬A",
+		"¬(A ∧ B) ≡ ¬A ∨ ¬B",
+		"(A ⇒ B) ∧ (B ⇒ C) ⇒ (A ⇒ C)",
+		"(if A then B else C) ≡ (A ∧ B) ∨ (¬A ∧ C)",
+		"atleast(3; a, b, c) ⇒ atleast(2; a, b)",
+		"(∀ x ∈ {m, n} : p(x)) ⇒ p(m)",
+		// 3 pigeons don't fit in 2 holes
+		"¬(atleast(1; a1, a2) ∧ atleast(1; b1, b2) ∧ " +
+			"atleast(1; c1, c2) ∧ atmost(1; a1, b1, c1) ∧ " +
+			"atmost(1; a2, b2, c2))",
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i]))
+		require.NoError(t, e)
+		r, e := Prove(p)
+		require.NoError(t, e, "At %d", i)
+		require.NoError(t, r.Check(), "At %d", i)
+		require.Empty(t, r.Lemmas[len(r.Lemmas)-1])
+		var sb strings.Builder
+		require.NoError(t, WriteDRAT(&sb, r))
+		require.True(t, strings.HasSuffix(sb.String(), "\n0\n") ||
+			sb.String() == "0\n")
+		require.Len(t, strings.Split(sb.String(), "\n"), len(r.Lemmas)+1)
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestProveRandom(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	inf := func(i int) {
+		// the negation of a random 3-CNF is a tautology when the
+		// CNF is unsatisfiable
+		n := 6 + i%6
+		cls := make([][]int, n*5)
+		var p *Predicate
+		for j := range cls {
+			var c *Predicate
+			for k := 0; k != 3; k++ {
+				v := 1 + rd.Intn(n)
+				l := NewTerm(fmt.Sprintf("x%d", v))
+				if rd.Intn(2) == 0 {
+					v, l = -v, &Predicate{Operator: NotOp, B: l}
+				}
+				cls[j] = append(cls[j], v)
+				c = join(c, OrOp, l)
+			}
+			p = join(p, AndOp, c)
+		}
+		r, e := Prove(&Predicate{Operator: NotOp, B: p})
+		var s *CounterexampleErr
+		if bruteSat(cls, n, nil) {
+			require.True(t, errors.As(e, &s), "At %d", i)
+			require.Equal(t, TTrue, Eval(p, MapEnv(toAny(s.Model)), Kleene))
+		} else {
+			require.NoError(t, e, "At %d", i)
+			require.NoError(t, r.Check(), "At %d", i)
+		}
+	}
+	alg.Forall(inf, 100)
+}
+
+// join returns a op b, or b when a is nil
+func join(a *Predicate, op string, b *Predicate) (r *Predicate) {
+	r = b
+	if a != nil {
+		r = &Predicate{Operator: op, A: a, B: b}
+	}
+	return
+}
+
+func toAny(m map[string]bool) (r map[string]any) {
+	r = make(map[string]any)
+	for k, v := range m {
+		r[k] = v
+	}
+	return
+}
+
+func TestProveErr(t *testing.T) {
+	p, e := Parse(strings.NewReader("A ⇒ A ∧ B"))
+	require.NoError(t, e)
+	_, e = Prove(p)
+	require.Equal(t, &CounterexampleErr{Model: map[string]bool{
+		"A": true, "B": false}}, e)
+	require.Equal(t, "Not a tautology, false with A, ¬B", e.Error())
+
+	p, e = Parse(strings.NewReader("(A ∨ B) ∧ (A ∨ ¬B) ⇒ A"))
+	require.NoError(t, e)
+	r, e := Prove(p)
+	require.NoError(t, e)
+	// without the empty clause it doesn't refute the CNF
+	r.Lemmas = r.Lemmas[:len(r.Lemmas)-1]
+	require.Equal(t, &ProofErr{Lemma: len(r.Lemmas)}, r.Check())
+	// every assignment of 1 and 2 falsifies a clause, but unit
+	// propagation needs the lemma 1 to find it
+	r = &Proof{
+		CNF: &CNF{NumVars: 2,
+			Clauses: [][]int{{1, 2}, {1, -2}, {-1, 2}, {-1, -2}}},
+		Lemmas: [][]int{{}},
+	}
+	require.Equal(t, &ProofErr{Lemma: 0}, r.Check())
+	require.Equal(t, "Lemma 0 isn't implied by unit propagation",
+		r.Check().Error())
+	r.Lemmas = [][]int{{1}, {}}
+	require.NoError(t, r.Check())
+}
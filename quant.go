@@ -0,0 +1,157 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+)
+
+// Domains maps domain names to their elements
+type Domains map[string][]string
+
+// UndefinedErr is returned when a quantifier ranges over a
+// domain that isn't defined
+type UndefinedErr struct {
+	Name string
+}
+
+func (u *UndefinedErr) Error() (s string) {
+	s = fmt.Sprintf("Undefined domain '%s'", u.Name)
+	return
+}
+
+// DomainsOf returns the domains declared among the statements
+func DomainsOf(ss []*Statement) (d Domains, e error) {
+	d = make(Domains)
+	for i := 0; e == nil && i != len(ss); i++ {
+		name := ss[i].Domain
+		if _, ok := d[name]; ok && name != "" {
+			e = &RedefinedErr{Name: name, Line: ss[i].Line}
+		} else if name != "" {
+			d[name] = ss[i].Elements
+		}
+	}
+	if e != nil {
+		d = nil
+	}
+	return
+}
+
+// Ground returns p with its quantifiers expanded over the
+// elements of their domains, ∀ as a conjunction and ∃ as a
+// disjunction, and the parameters of its atoms replaced by the
+// elements bound to them. A quantifier
+// over an empty domain is true when it's ∀, and false when it's
+// ∃
+func Ground(p *Predicate, d Domains) (r *Predicate, e error) {
+	r, e = ground(p, d, nil)
+	return
+}
+
+// ground uses env for the elements bound to the quantified
+// names
+func ground(p *Predicate, d Domains,
+	env map[string]string) (r *Predicate, e error) {
+	if p.Operator == Term {
+		r = NewTerm(p.String)
+		if x, ok := env[p.String]; ok {
+			r.String = x
+		}
+	} else if p.Operator == AtomOp {
+		r = &Predicate{Operator: AtomOp, String: p.String}
+		for _, x := range p.Args {
+			r.Args = append(r.Args, NewTerm(x.String))
+			if v, ok := env[x.String]; ok {
+				r.Args[len(r.Args)-1].String = v
+			}
+		}
+	} else if isQuant(p.Operator) {
+		var es []string
+		if p.Domain != "" {
+			var ok bool
+			es, ok = d[p.Domain]
+			if !ok {
+				e = &UndefinedErr{Name: p.Domain}
+			}
+		} else {
+			// the elements may be names bound by outer quantifiers
+			for _, x := range p.Args {
+				v, ok := env[x.String]
+				if !ok {
+					v = x.String
+				}
+				es = append(es, v)
+			}
+		}
+		op, unit := AndOp, True()
+		if p.Operator == ExistsOp {
+			op, unit = OrOp, False()
+		}
+		for i := 0; e == nil && i != len(es); i++ {
+			var b *Predicate
+			b, e = ground(p.B, d, bind(env, p.String, es[i]))
+			if e == nil && r == nil {
+				r = b
+			} else if e == nil {
+				r = &Predicate{Operator: op, A: r, B: b}
+			}
+		}
+		if e == nil && r == nil {
+			r = unit
+		}
+	} else {
+		r = &Predicate{Operator: p.Operator, String: p.String, K: p.K}
+		nenv := env
+		if p.Operator == LetOp {
+			// the let bound name hides the quantified one
+			nenv = bind(env, p.String, p.String)
+		}
+		if p.A != nil {
+			r.A, e = ground(p.A, d, env)
+		}
+		if e == nil && p.B != nil {
+			r.B, e = ground(p.B, d, nenv)
+		}
+		if e == nil && p.C != nil {
+			r.C, e = ground(p.C, d, env)
+		}
+		for i := 0; e == nil && i != len(p.Args); i++ {
+			var a *Predicate
+			a, e = ground(p.Args[i], d, env)
+			r.Args = append(r.Args, a)
+		}
+	}
+	if e != nil {
+		r = nil
+	}
+	return
+}
+
+// bind returns a copy of env where name is bound to x
+func bind(env map[string]string, name, x string) (r map[string]string) {
+	r = map[string]string{name: x}
+	for k, v := range env {
+		if k != name {
+			r[k] = v
+		}
+	}
+	return
+}
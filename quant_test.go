@@ -0,0 +1,154 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseQuantifier(t *testing.T) {
+	ps := []struct {
+		pred, str string
+	}{
+		{"∀ u ∈ users : active(u) ⇒ verified(u)",
+			"∀ u ∈ users : active(u) ⇒ verified(u)"},
+		{"∃ x∈{a,b}:p(x, y)", "∃ x ∈ {a, b} : p(x, y)"},
+		{"∀ x ∈ {} : A", "∀ x ∈ {} : A"},
+		{"A ∧ ∃ x ∈ D : ¬q(x)", "A ∧ (∃ x ∈ D : ¬q(x))"},
+		{"¬(∀ x ∈ D : x) ∨ r(a)", "¬(∀ x ∈ D : x) ∨ r(a)"},
+		{"let y := B in ∀ x ∈ D : x ∧ y", "let y := B in ∀ x ∈ D : x ∧ y"},
+		{"∀ x ∈ D : ∃ y ∈ D : edge(x, y)",
+			"∀ x ∈ D : ∃ y ∈ D : edge(x, y)"},
+		{"(∀ x ∈ D : p(x) ∨ q(x)) ∧ A", "(∀ x ∈ D : p(x) ∨ q(x)) ∧ A"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		require.True(t, p.Valid(), "At %d", i)
+		require.Equal(t, ps[i].str, String(p), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	errs := []struct {
+		pred string
+		e    error
+	}{
+		{"∀ in ∈ D : A", &NotRecognizedErr{
+			String: InKw, Expecting: []string{Identifier}}},
+		{"∀ x D : A", &NotRecognizedErr{
+			String: "D", Expecting: []string{InOp}}},
+		{"∀ x ∈ D A", &NotRecognizedErr{
+			String: "A", Expecting: []string{Colon}}},
+		{"∀ x ∈ ¬ : A", &NotRecognizedErr{
+			String: NotOp, Expecting: []string{Identifier, OBrace}}},
+		{"∀ x ∈ {a b} : A", &NotRecognizedErr{
+			String: "b", Expecting: []string{Comma, CBrace}}},
+		{"∀ x ∈ D : p(x) ∨ q(x) ∧ A", &NotRecognizedErr{
+			String: AndOp, Expecting: []string{OrOp, ImpliesOp, FollowsOp,
+				EquivalesOp, NotEquivalesOp, XorOp}}},
+		{"p()", &NotRecognizedErr{
+			String: CPar, Expecting: []string{Identifier}}},
+		{"p(a,)", &NotRecognizedErr{
+			String: CPar, Expecting: []string{Identifier}}},
+	}
+	inf = func(i int) {
+		_, e := Parse(strings.NewReader(errs[i].pred))
+		require.Equal(t, errs[i].e, e, "At %d", i)
+	}
+	alg.Forall(inf, len(errs))
+}
+
+func TestGround(t *testing.T) {
+	d := Domains{"users": {"alice", "bob"}, "none": {}}
+	ps := []struct {
+		pred, ground string
+	}{
+		{"∀ u ∈ users : active(u) ⇒ verified(u)",
+			"(active(alice) ⇒ verified(alice)) ∧ " +
+				"(active(bob) ⇒ verified(bob))"},
+		{"∃ x ∈ {a, b, c} : x", "a ∨ b ∨ c"},
+		{"∀ x ∈ none : p(x)", "true"},
+		{"∃ x ∈ none : p(x)", "false"},
+		{"∀ x ∈ users : ∃ y ∈ {x, carol} : knows(x, y)",
+			"(knows(alice, alice) ∨ knows(alice, carol)) ∧ " +
+				"(knows(bob, bob) ∨ knows(bob, carol))"},
+		{"∀ x ∈ users : let x := A in x", "(let x := A in x) ∧ " +
+			"(let x := A in x)"},
+		{"atmost(1; ∃ u ∈ users : p(u), q(x))",
+			"atmost(1; p(alice) ∨ p(bob), q(x))"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		g, e := Ground(p, d)
+		require.NoError(t, e, "At %d", i)
+		require.True(t, g.Valid(), "At %d", i)
+		require.False(t, hasOperator(g, isQuant), "At %d", i)
+		require.Equal(t, ps[i].ground, String(g), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	p, e := Parse(strings.NewReader("∀ x ∈ groups : p(x)"))
+	require.NoError(t, e)
+	_, e = Ground(p, d)
+	require.Equal(t, &UndefinedErr{Name: "groups"}, e)
+	// named domains aren't reduced
+	require.Equal(t, String(p), String(Reduce(p, constInterp)))
+	require.Empty(t, Vars(p))
+
+	p, e = Parse(strings.NewReader("∃ x ∈ {a, b} : p(x) ∧ q(c)"))
+	require.NoError(t, e)
+	itp := func(name string) (v, ok bool) {
+		v, ok = constInterp(name)
+		if !ok {
+			v, ok = name == "p(b)", name == "p(b)" || name == "p(a)"
+		}
+		return
+	}
+	require.Equal(t, "q(c)", String(Reduce(p, itp)))
+	require.Equal(t, []string{"q(c)"}, Vars(p))
+}
+
+func TestDomainsOf(t *testing.T) {
+	txt := "domain users := {alice, bob};\n" +
+		"domain empty := {};\n" +
+		"∀ u ∈ users : active(u)"
+	ss := ParseAll(strings.NewReader(txt))
+	require.Len(t, ss, 3)
+	require.Nil(t, ss[0].Predicate)
+	require.Equal(t, []string{"alice", "bob"}, ss[0].Elements)
+	d, e := DomainsOf(ss)
+	require.NoError(t, e)
+	require.Equal(t, Domains{"users": {"alice", "bob"}, "empty": nil}, d)
+
+	ss = ParseAll(strings.NewReader("domain a := {x}; domain a := {y}"))
+	_, e = DomainsOf(ss)
+	require.Equal(t, &RedefinedErr{Name: "a", Line: 1}, e)
+
+	ss = ParseAll(strings.NewReader("domain a := x; A"))
+	require.Len(t, ss, 2)
+	require.Equal(t, &NotRecognizedErr{String: "x",
+		Expecting: []string{OBrace}}, ss[0].Error)
+	require.Equal(t, "", ss[0].Domain)
+}
@@ -0,0 +1,176 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Resolver is an Env with the values of a document, a JSON
+// object decoded as map[string]any or a Go struct, where
+// identifiers are paths of keys and fields separated by dots,
+// like user.profile.admin. Struct fields are named by their
+// tag, or by their name when they don't have one, and slice
+// elements by their indexes. Fields tagged "-" are skipped, and
+// the ones of embedded structs are found like they were fields
+// of the enclosing struct
+type Resolver struct {
+	Doc any
+	// Tag is the key of the struct tags naming fields, json
+	// when empty
+	Tag string
+}
+
+// NewResolver returns a Resolver for doc that names struct
+// fields by their json tags
+func NewResolver(doc any) (r *Resolver) {
+	r = &Resolver{Doc: doc}
+	return
+}
+
+// Bool returns the value at the path name when it's a boolean.
+// The constants true and false are defined
+func (r *Resolver) Bool(name string) (v, ok bool) {
+	x, found := r.resolve(name)
+	if found && x.Kind() == reflect.Bool {
+		v, ok = x.Bool(), true
+	} else if name == TrueStr || name == FalseStr {
+		v, ok = name == TrueStr, true
+	}
+	return
+}
+
+// Value returns the value at the path name when it isn't a
+// boolean. JSON numbers are returned as float64
+func (r *Resolver) Value(name string) (v any, ok bool) {
+	x, found := r.resolve(name)
+	ok = found && x.Kind() != reflect.Bool && x.CanInterface()
+	if ok {
+		v = x.Interface()
+	}
+	if n, isNum := v.(json.Number); ok && isNum {
+		var e error
+		v, e = n.Float64()
+		ok = e == nil
+	}
+	return
+}
+
+// NameBool returns the boolean values of r as a NameBool
+func (r *Resolver) NameBool() (n NameBool) {
+	n = r.Bool
+	return
+}
+
+// resolve returns the value at path, following pointers and
+// interfaces
+func (r *Resolver) resolve(path string) (x reflect.Value, ok bool) {
+	x, ok = deref(reflect.ValueOf(r.Doc))
+	ks := strings.Split(path, ".")
+	for i := 0; ok && i != len(ks); i++ {
+		x, ok = r.field(x, ks[i])
+		if ok {
+			x, ok = deref(x)
+		}
+	}
+	return
+}
+
+// field returns the element of x named k
+func (r *Resolver) field(x reflect.Value, k string) (y reflect.Value,
+	ok bool) {
+	switch x.Kind() {
+	case reflect.Map:
+		if x.Type().Key().Kind() == reflect.String {
+			y = x.MapIndex(reflect.ValueOf(k).Convert(x.Type().Key()))
+			ok = y.IsValid()
+		}
+	case reflect.Struct:
+		tag := r.Tag
+		if tag == "" {
+			tag = "json"
+		}
+		t := x.Type()
+		// the fields of embedded structs without a name in their
+		// tag are searched after the rest, like encoding/json
+		// does
+		var embedded []reflect.Value
+		for i := 0; !ok && i != t.NumField(); i++ {
+			f := t.Field(i)
+			tv := f.Tag.Get(tag)
+			name := strings.Split(tv, ",")[0]
+			z, set := deref(x.Field(i))
+			// fields tagged "-" are ignored
+			skip := tv == "-"
+			if !skip && f.Anonymous && name == "" && set &&
+				z.Kind() == reflect.Struct {
+				embedded = append(embedded, z)
+			} else if !skip {
+				if name == "" {
+					name = f.Name
+				}
+				ok = f.IsExported() && name == k
+			}
+			if ok {
+				y = x.Field(i)
+			}
+		}
+		for i := 0; !ok && i != len(embedded); i++ {
+			y, ok = r.field(embedded[i], k)
+		}
+	case reflect.Slice, reflect.Array:
+		n, e := strconv.Atoi(k)
+		ok = e == nil && n >= 0 && n < x.Len()
+		if ok {
+			y = x.Index(n)
+		}
+	}
+	return
+}
+
+// deref returns the value x points to, or that x holds when
+// it's an interface, and whether it isn't nil
+func deref(x reflect.Value) (y reflect.Value, ok bool) {
+	y, ok = x, x.IsValid()
+	for ok && (y.Kind() == reflect.Ptr || y.Kind() == reflect.Interface) {
+		ok = !y.IsNil()
+		if ok {
+			y = y.Elem()
+		}
+	}
+	return
+}
+
+// Filter returns the elements of xs that satisfy p, resolving
+// its identifiers in each element with a Resolver. Elements for
+// which p isn't reduced to true are excluded
+func Filter[T any](p *Predicate, xs []T) (ys []T) {
+	for _, x := range xs {
+		r := ReduceEnv(p, NewResolver(x))
+		if r.Operator == Term && r.String == TrueStr {
+			ys = append(ys, x)
+		}
+	}
+	return
+}
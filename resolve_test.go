@@ -0,0 +1,130 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"encoding/json"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type profile struct {
+	Admin bool   `json:"admin"`
+	Name  string `json:"name,omitempty"`
+	Age   *int
+	Tags  []string `json:"tags"`
+	note  string
+}
+
+type user struct {
+	ID      int      `json:"id" db:"user_id"`
+	Profile *profile `json:"profile"`
+	Active  bool     `json:"active"`
+}
+
+type audit struct {
+	Created int `json:"created"`
+	Secret  int `json:"-"`
+}
+
+type account struct {
+	*user
+	audit
+	Owner  profile `json:"owner"`
+	Hidden bool    `json:"-"`
+	Dash   bool    `json:"-,"`
+	ID     string  `json:"id"`
+}
+
+func TestResolver(t *testing.T) {
+	doc := `{"user": {"profile": {"admin": true, "age": 30,
+		"tags": ["a", "b"]}, "country": "CU", "nothing": null}}`
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(doc), &m))
+	d := json.NewDecoder(strings.NewReader(doc))
+	d.UseNumber()
+	var n map[string]any
+	require.NoError(t, d.Decode(&n))
+	age := 41
+	u := &user{ID: 7, Profile: &profile{Name: "ana", Age: &age,
+		Tags: []string{"x"}, note: "n"}}
+	acc := &account{user: &user{ID: 7, Active: true, Profile: u.Profile},
+		audit: audit{Created: 3, Secret: 1}, Owner: profile{Admin: true},
+		Hidden: true, Dash: true, ID: "a7"}
+	ps := []struct {
+		doc       any
+		pred, red string
+	}{
+		{m, "user.profile.admin", "true"},
+		{m, `user.profile.age ≥ 18 ∧ user.country = "CU"`, "true"},
+		{m, `user.profile.tags.1 = "b"`, "true"},
+		{m, `user.profile.tags.2 = "b"`, `user.profile.tags.2 = "b"`},
+		{m, "user.nothing ∨ user.missing", "user.nothing ∨ user.missing"},
+		{m, "user.profile ∧ true", "user.profile"},
+		{n, "user.profile.age < 30.5", "true"},
+		{u, `id = 7 ∧ profile.name = "ana" ∧ ¬active`, "true"},
+		{u, "profile.Age > 40 ∧ profile.tags.0 ∈ {\"x\"}", "true"},
+		{u, "profile.note = 1 ∨ profile.admin", "profile.note = 1"},
+		{nil, "a ∧ false", "false"},
+		{acc, `created = 3 ∧ active ∧ id = "a7" ∧ profile.name = "ana"`,
+			"true"},
+		{acc, "owner.admin ∧ `-` ∧ Hidden ∧ hidden ∧ Secret ∧ secret",
+			"Hidden ∧ hidden ∧ Secret ∧ secret"},
+		{&account{audit: audit{Created: 3}}, "created = 3 ∧ active",
+			"active"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e, "At %d", i)
+		r := ReduceEnv(p, NewResolver(ps[i].doc))
+		require.Equal(t, ps[i].red, String(r), "At %d", i)
+	}
+	alg.Forall(inf, len(ps))
+
+	r := &Resolver{Doc: u, Tag: "db"}
+	x, ok := r.Value("user_id")
+	require.True(t, ok)
+	require.Equal(t, 7, x)
+	_, ok = r.Value("id")
+	require.False(t, ok)
+	v, ok := NewResolver(m).NameBool()("user.profile.admin")
+	require.True(t, v && ok)
+}
+
+func TestFilter(t *testing.T) {
+	us := []user{
+		{ID: 1, Active: true, Profile: &profile{Admin: true}},
+		{ID: 2, Active: true},
+		{ID: 3, Profile: &profile{Admin: true}},
+		{ID: 4, Active: true, Profile: &profile{Admin: false}},
+	}
+	p, e := Parse(strings.NewReader("active ∧ (profile.admin ∨ id ≥ 4)"))
+	require.NoError(t, e)
+	fs := Filter(p, us)
+	ids := make([]int, len(fs))
+	for i, u := range fs {
+		ids[i] = u.ID
+	}
+	// the second isn't included since profile.admin is unknown
+	require.Equal(t, []int{1, 4}, ids)
+}
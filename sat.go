@@ -0,0 +1,358 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+// cdcl is a SAT solver by conflict driven clause learning,
+// over variables numbered from 1 and literals like the ones of
+// CNF. It keeps the learned clauses between calls to solve,
+// which can assume literals that hold only during that call
+type cdcl struct {
+	clauses [][]int
+	// watches has, for each literal, the clauses where it's
+	// one of the first two literals, which are watched for
+	// becoming false
+	watches [][]int
+	// value, level and reason are indexed by variable, and
+	// reason is -1 for decisions and the level 0 assignments
+	value  []int
+	level  []int
+	reason []int
+	trail  []int
+	// lims has the trail length when each decision level
+	// started
+	lims     []int
+	qhead    int
+	activity []float64
+	inc      float64
+	seen     []bool
+	// unsat is true when the clauses are unsatisfiable without
+	// assumptions
+	unsat bool
+	// learned receives the clauses learned from conflicts,
+	// when it isn't nil
+	learned func([]int)
+}
+
+func newCDCL() (s *cdcl) {
+	s = &cdcl{inc: 1}
+	// variable 0 isn't used
+	s.grow(0)
+	return
+}
+
+// grow makes room for the variables up to n
+func (s *cdcl) grow(n int) {
+	for len(s.value) <= n {
+		s.value = append(s.value, 0)
+		s.level = append(s.level, 0)
+		s.reason = append(s.reason, -1)
+		s.activity = append(s.activity, 0)
+		s.seen = append(s.seen, false)
+		s.watches = append(s.watches, nil, nil)
+	}
+}
+
+// numVars returns the greatest variable in s
+func (s *cdcl) numVars() (n int) {
+	n = len(s.value) - 1
+	return
+}
+
+// litIndex returns the index of l in watches
+func litIndex(l int) (i int) {
+	i = 2 * l
+	if l < 0 {
+		i = -2*l + 1
+	}
+	return
+}
+
+// litValue returns 1 when l is true, -1 when false and 0 when
+// its variable isn't assigned
+func (s *cdcl) litValue(l int) (v int) {
+	v = s.value[abs(l)]
+	if l < 0 {
+		v = -v
+	}
+	return
+}
+
+func (s *cdcl) decisionLevel() (n int) {
+	n = len(s.lims)
+	return
+}
+
+// assign makes l true because of the clause reason
+func (s *cdcl) assign(l, reason int) {
+	v := abs(l)
+	s.value[v] = 1
+	if l < 0 {
+		s.value[v] = -1
+	}
+	s.level[v], s.reason[v] = s.decisionLevel(), reason
+	s.trail = append(s.trail, l)
+}
+
+// addClause adds cl at level 0, simplified by the assignments
+// of that level
+func (s *cdcl) addClause(cl []int) {
+	s.backtrack(0)
+	var ls []int
+	sat := false
+	for _, l := range cl {
+		s.grow(abs(l))
+		dup := false
+		for _, x := range ls {
+			dup = dup || x == l
+			sat = sat || x == -l
+		}
+		sat = sat || s.litValue(l) == 1
+		if !dup && s.litValue(l) == 0 {
+			ls = append(ls, l)
+		}
+	}
+	if !sat && !s.unsat {
+		if len(ls) == 0 {
+			s.unsat = true
+		} else if len(ls) == 1 {
+			s.assign(ls[0], -1)
+			s.unsat = s.propagate() != -1
+		} else {
+			s.attach(ls)
+		}
+	}
+}
+
+// attach adds cl to the clauses, watching its first two
+// literals, and returns its index
+func (s *cdcl) attach(cl []int) (i int) {
+	i = len(s.clauses)
+	s.clauses = append(s.clauses, cl)
+	s.watches[litIndex(cl[0])] = append(s.watches[litIndex(cl[0])], i)
+	s.watches[litIndex(cl[1])] = append(s.watches[litIndex(cl[1])], i)
+	return
+}
+
+// propagate assigns the literals implied by unit clauses, and
+// returns the index of a clause with every literal false, or
+// -1 when there's none
+func (s *cdcl) propagate() (confl int) {
+	confl = -1
+	for confl == -1 && s.qhead != len(s.trail) {
+		f := -s.trail[s.qhead]
+		s.qhead = s.qhead + 1
+		ws := s.watches[litIndex(f)]
+		j := 0
+		for i := 0; i != len(ws); i++ {
+			c := s.clauses[ws[i]]
+			if c[0] == f {
+				c[0], c[1] = c[1], c[0]
+			}
+			// c[1] is f, which became false
+			moved := false
+			if s.litValue(c[0]) != 1 && confl == -1 {
+				for k := 2; !moved && k != len(c); k++ {
+					if s.litValue(c[k]) != -1 {
+						c[1], c[k] = c[k], c[1]
+						x := litIndex(c[1])
+						s.watches[x] = append(s.watches[x], ws[i])
+						moved = true
+					}
+				}
+			}
+			if !moved {
+				ws[j] = ws[i]
+				j = j + 1
+				if confl == -1 && s.litValue(c[0]) == -1 {
+					confl = ws[i]
+				} else if confl == -1 && s.litValue(c[0]) == 0 {
+					s.assign(c[0], ws[i])
+				}
+			}
+		}
+		s.watches[litIndex(f)] = ws[:j]
+	}
+	return
+}
+
+// analyze returns the clause learned from the conflict, by
+// resolution until a single literal of the current level
+// remains, and the level to backtrack to. The asserting
+// literal is the first one of the clause and the second one
+// has the backtracking level
+func (s *cdcl) analyze(confl int) (learnt []int, bt int) {
+	learnt = []int{0}
+	// pending is the amount of literals of the current level
+	// that haven't been resolved
+	pending, p, i := 0, 0, len(s.trail)-1
+	for confl != -1 {
+		for _, q := range s.clauses[confl] {
+			v := abs(q)
+			if q != p && !s.seen[v] && s.level[v] > 0 {
+				s.seen[v] = true
+				s.bump(v)
+				if s.level[v] == s.decisionLevel() {
+					pending = pending + 1
+				} else {
+					learnt = append(learnt, q)
+				}
+			}
+		}
+		for !s.seen[abs(s.trail[i])] {
+			i = i - 1
+		}
+		p = s.trail[i]
+		i = i - 1
+		s.seen[abs(p)] = false
+		pending = pending - 1
+		confl = s.reason[abs(p)]
+		if pending == 0 {
+			confl = -1
+		}
+	}
+	learnt[0] = -p
+	for k, q := range learnt {
+		s.seen[abs(q)] = false
+		if k > 1 && s.level[abs(q)] > s.level[abs(learnt[1])] {
+			learnt[1], learnt[k] = learnt[k], learnt[1]
+		}
+	}
+	if len(learnt) > 1 {
+		bt = s.level[abs(learnt[1])]
+	}
+	return
+}
+
+// final returns the assumptions that imply the literal l, which
+// is the negation of an assumption, including that assumption
+func (s *cdcl) final(l int) (core []int) {
+	core = []int{-l}
+	s.seen[abs(l)] = true
+	start := len(s.trail)
+	if len(s.lims) != 0 {
+		start = s.lims[0]
+	}
+	for i := len(s.trail) - 1; i >= start; i-- {
+		v := abs(s.trail[i])
+		if s.seen[v] && s.reason[v] == -1 {
+			// decisions are assumptions at this point
+			core = append(core, s.trail[i])
+		} else if s.seen[v] {
+			for _, q := range s.clauses[s.reason[v]][1:] {
+				if s.level[abs(q)] > 0 {
+					s.seen[abs(q)] = true
+				}
+			}
+		}
+		s.seen[v] = false
+	}
+	s.seen[abs(l)] = false
+	return
+}
+
+func (s *cdcl) bump(v int) {
+	s.activity[v] = s.activity[v] + s.inc
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] = s.activity[i] * 1e-100
+		}
+		s.inc = s.inc * 1e-100
+	}
+}
+
+// backtrack undoes the assignments of the levels after lvl
+func (s *cdcl) backtrack(lvl int) {
+	if s.decisionLevel() > lvl {
+		for _, l := range s.trail[s.lims[lvl]:] {
+			s.value[abs(l)], s.reason[abs(l)] = 0, -1
+		}
+		s.trail = s.trail[:s.lims[lvl]]
+		s.lims = s.lims[:lvl]
+		s.qhead = len(s.trail)
+	}
+}
+
+// decide returns the unassigned variable with the greatest
+// activity, or 0 when every variable is assigned
+func (s *cdcl) decide() (v int) {
+	for i := 1; i <= s.numVars(); i++ {
+		if s.value[i] == 0 && (v == 0 || s.activity[i] > s.activity[v]) {
+			v = i
+		}
+	}
+	return
+}
+
+// solve returns whether the clauses are satisfiable with the
+// assumptions true. When they aren't, core has the assumptions
+// that make them unsatisfiable, which is empty when the
+// clauses are unsatisfiable by themselves. After a satisfiable
+// call, value has a model
+func (s *cdcl) solve(assumptions []int) (ok bool, core []int) {
+	for _, a := range assumptions {
+		s.grow(abs(a))
+	}
+	s.backtrack(0)
+	if !s.unsat && s.propagate() != -1 {
+		s.unsat = true
+	}
+	done := s.unsat
+	conflicts, restart := 0, 100
+	for !done {
+		confl := s.propagate()
+		if confl != -1 && s.decisionLevel() == 0 {
+			s.unsat, done = true, true
+		} else if confl != -1 {
+			learnt, bt := s.analyze(confl)
+			s.backtrack(bt)
+			if s.learned != nil {
+				s.learned(learnt)
+			}
+			if len(learnt) == 1 {
+				s.assign(learnt[0], -1)
+			} else {
+				s.assign(learnt[0], s.attach(learnt))
+			}
+			s.inc = s.inc / 0.95
+			conflicts = conflicts + 1
+			if conflicts == restart {
+				conflicts, restart = 0, restart+restart/2
+				s.backtrack(0)
+			}
+		} else if s.decisionLevel() < len(assumptions) {
+			a := assumptions[s.decisionLevel()]
+			if s.litValue(a) == -1 {
+				core, done = s.final(-a), true
+			} else {
+				s.lims = append(s.lims, len(s.trail))
+				if s.litValue(a) == 0 {
+					s.assign(a, -1)
+				}
+			}
+		} else if v := s.decide(); v != 0 {
+			s.lims = append(s.lims, len(s.trail))
+			s.assign(-v, -1)
+		} else {
+			ok, done = true, true
+		}
+	}
+	return
+}
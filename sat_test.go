@@ -0,0 +1,116 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestCDCL(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	inf := func(i int) {
+		// random 3-CNF around the satisfiability threshold
+		n := 4 + i%8
+		cls := make([][]int, n*4+rd.Intn(n))
+		for j := range cls {
+			cls[j] = make([]int, 3)
+			for k := range cls[j] {
+				cls[j][k] = 1 + rd.Intn(n)
+				if rd.Intn(2) == 0 {
+					cls[j][k] = -cls[j][k]
+				}
+			}
+		}
+		s := newCDCL()
+		for _, cl := range cls {
+			s.addClause(cl)
+		}
+		as := []int{1 + rd.Intn(n), -1 - rd.Intn(n)}
+		ok, core := s.solve(as)
+		require.Equal(t, bruteSat(cls, n, as), ok, "At %d", i)
+		if ok {
+			require.True(t, satisfies(cls, s.value), "At %d", i)
+			for _, a := range as {
+				require.Equal(t, 1, s.litValue(a), "At %d", i)
+			}
+		} else {
+			require.False(t, bruteSat(cls, n, core), "At %d", i)
+			for _, l := range core {
+				require.Contains(t, as, l, "At %d", i)
+			}
+		}
+		ok, _ = s.solve(nil)
+		require.Equal(t, bruteSat(cls, n, nil), ok, "At %d", i)
+	}
+	alg.Forall(inf, 200)
+}
+
+func TestPigeonhole(t *testing.T) {
+	// 5 pigeons in 4 holes, x(p, h) is 4p+h+1
+	s := newCDCL()
+	x := func(p, h int) int { return 4*p + h + 1 }
+	for p := 0; p != 5; p++ {
+		s.addClause([]int{x(p, 0), x(p, 1), x(p, 2), x(p, 3)})
+	}
+	for h := 0; h != 4; h++ {
+		for p := 0; p != 5; p++ {
+			for q := p + 1; q != 5; q++ {
+				s.addClause([]int{-x(p, h), -x(q, h)})
+			}
+		}
+	}
+	ok, core := s.solve(nil)
+	require.False(t, ok)
+	require.Empty(t, core)
+	require.True(t, s.unsat)
+}
+
+func bruteSat(cls [][]int, n int, as []int) (ok bool) {
+	for m := 0; !ok && m != 1<<n; m++ {
+		value := make([]int, n+1)
+		for v := 1; v <= n; v++ {
+			value[v] = -1
+			if m&(1<<(v-1)) != 0 {
+				value[v] = 1
+			}
+		}
+		ok = satisfies(cls, value)
+		for _, a := range as {
+			ok = ok && satisfies([][]int{{a}}, value)
+		}
+	}
+	return
+}
+
+func satisfies(cls [][]int, value []int) (ok bool) {
+	ok = true
+	for _, cl := range cls {
+		sat := false
+		for _, l := range cl {
+			sat = sat || (l > 0 && value[l] == 1) || (l < 0 && value[-l] == -1)
+		}
+		ok = ok && sat
+	}
+	return
+}
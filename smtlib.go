@@ -0,0 +1,451 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WriteSMTLIB writes an SMT-LIB 2 script that declares every
+// variable in p as Bool, asserts p and asks for a model. Its
+// quantifiers are grounded like in the propositional form
+// described in UnsupportedErr, while comparisons are kept
+func WriteSMTLIB(w io.Writer, p *Predicate) (e error) {
+	p, e = Ground(p, nil)
+	if e == nil {
+		if n := smtInvalid(p); n != "" {
+			e = &SMTSymbolErr{Name: n}
+		}
+	}
+	if e == nil {
+		var sb strings.Builder
+		logic := "QF_UF"
+		if hasOperator(p, isComparison) {
+			// comparisons use reals and strings
+			logic = "ALL"
+		} else if hasOperator(p, isCard) {
+			// cardinality constraints are sums of integers
+			logic = "QF_LIA"
+		}
+		fmt.Fprintf(&sb, "(set-logic %s)\n", logic)
+		for _, v := range Vars(p) {
+			fmt.Fprintf(&sb, "(declare-fun %s () Bool)\n", smtSymbol(v))
+		}
+		sorts := make(map[string]string)
+		smtSorts(p, sorts)
+		names := make([]string, 0, len(sorts))
+		for k := range sorts {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, v := range names {
+			fmt.Fprintf(&sb, "(declare-fun %s () %s)\n", smtSymbol(v),
+				sorts[v])
+		}
+		fmt.Fprintf(&sb, "(assert %s)\n", smtTerm(p))
+		sb.WriteString("(check-sat)\n(get-model)\n")
+		_, e = io.WriteString(w, sb.String())
+	}
+	return
+}
+
+func smtTerm(p *Predicate) (r string) {
+	ops := map[string]string{
+		AndOp:          "and",
+		OrOp:           "or",
+		ImpliesOp:      "=>",
+		EquivalesOp:    "=",
+		NotEquivalesOp: "xor",
+		XorOp:          "xor",
+		EqOp:           "=",
+		NeOp:           "distinct",
+		LtOp:           "<",
+		LeOp:           "<=",
+		GtOp:           ">",
+		GeOp:           ">=",
+	}
+	if p.Operator == Term {
+		r = p.String
+		if r != TrueStr && r != FalseStr {
+			r = smtSymbol(r)
+		}
+	} else if p.Operator == AtomOp {
+		r = smtSymbol(String(p))
+	} else if p.Operator == VarOp {
+		r = smtSymbol(p.String)
+	} else if p.Operator == NumberOp {
+		r = smtNumber(p.String)
+	} else if p.Operator == StringOp {
+		r = smtString(p.String)
+	} else if p.Operator == InOp {
+		eqs := make([]string, len(p.Args))
+		for i, x := range p.Args {
+			eqs[i] = fmt.Sprintf("(= %s %s)", smtTerm(p.A), smtTerm(x))
+		}
+		if len(eqs) == 0 {
+			r = FalseStr
+		} else if len(eqs) == 1 {
+			r = eqs[0]
+		} else {
+			r = "(or " + strings.Join(eqs, " ") + ")"
+		}
+	} else if p.Operator == NotOp {
+		r = fmt.Sprintf("(not %s)", smtTerm(p.B))
+	} else if p.Operator == LetOp {
+		r = fmt.Sprintf("(let ((%s %s)) %s)", smtSymbol(p.String),
+			smtTerm(p.A), smtTerm(p.B))
+	} else if isCard(p.Operator) {
+		rel := map[string]string{
+			AtMostOp:  "<=",
+			AtLeastOp: ">=",
+			ExactlyOp: "=",
+		}[p.Operator]
+		sum := make([]string, len(p.Args))
+		for i, a := range p.Args {
+			sum[i] = fmt.Sprintf("(ite %s 1 0)", smtTerm(a))
+		}
+		// + takes at least two arguments
+		total := "0"
+		if len(sum) == 1 {
+			total = sum[0]
+		} else if len(sum) != 0 {
+			total = "(+ " + strings.Join(sum, " ") + ")"
+		}
+		r = fmt.Sprintf("(%s %s %d)", rel, total, p.K)
+	} else if p.Operator == IfOp {
+		r = fmt.Sprintf("(ite %s %s %s)", smtTerm(p.A), smtTerm(p.B),
+			smtTerm(p.C))
+	} else if p.Operator == NandOp || p.Operator == NorOp {
+		op := map[string]string{NandOp: "and", NorOp: "or"}[p.Operator]
+		r = fmt.Sprintf("(not (%s %s %s))", op, smtTerm(p.A),
+			smtTerm(p.B))
+	} else if p.Operator == FollowsOp {
+		// a ⇐ b ≡ b ⇒ a
+		r = fmt.Sprintf("(=> %s %s)", smtTerm(p.B), smtTerm(p.A))
+	} else {
+		r = fmt.Sprintf("(%s %s %s)", ops[p.Operator], smtTerm(p.A),
+			smtTerm(p.B))
+	}
+	return
+}
+
+// smtSorts sets in sorts the sort of each variable compared in
+// p, which is String when it's compared with a string and Real
+// otherwise
+func smtSorts(p *Predicate, sorts map[string]string) {
+	if isComparison(p.Operator) {
+		vs := append([]*Predicate{p.A}, p.Args...)
+		if p.B != nil {
+			vs = append(vs, p.B)
+		}
+		srt := "Real"
+		for _, v := range vs {
+			if v.Operator == StringOp {
+				srt = "String"
+			}
+		}
+		for _, v := range vs {
+			if v.Operator == VarOp && sorts[v.String] != "String" {
+				sorts[v.String] = srt
+			}
+		}
+	} else {
+		for _, c := range children(p) {
+			smtSorts(c, sorts)
+		}
+	}
+}
+
+// smtNumber returns the number n as an SMT-LIB real
+func smtNumber(n string) (r string) {
+	r = strings.TrimPrefix(n, "-")
+	if !strings.Contains(r, ".") {
+		r = r + ".0"
+	}
+	if strings.HasPrefix(n, "-") {
+		r = "(- " + r + ")"
+	}
+	return
+}
+
+// smtString returns s as an SMT-LIB string literal, where
+// quotes are doubled and the characters outside printable
+// ASCII are escaped
+func smtString(s string) (r string) {
+	var sb strings.Builder
+	sb.WriteRune('"')
+	for _, rn := range s {
+		if rn == '"' {
+			sb.WriteString(`""`)
+		} else if rn < ' ' || rn > '~' {
+			fmt.Fprintf(&sb, `\u{%x}`, rn)
+		} else {
+			sb.WriteRune(rn)
+		}
+	}
+	sb.WriteRune('"')
+	r = sb.String()
+	return
+}
+
+// smtSymbol returns s as a simple SMT-LIB symbol when possible,
+// otherwise as a quoted one. Reserved words, the symbols of the
+// theories used by WriteSMTLIB, and the prefixes reserved for
+// them and for solvers are quoted
+func smtSymbol(s string) (r string) {
+	simple := s != "" && !smtReserved[s] && !strings.HasPrefix(s, "@") &&
+		!strings.HasPrefix(s, ".")
+	for _, x := range []string{"str.", "re.", "seq."} {
+		simple = simple && !strings.HasPrefix(s, x)
+	}
+	for i, rn := range s {
+		isSym := rn < unicode.MaxASCII &&
+			(unicode.IsLetter(rn) || strings.ContainsRune(smtSymChars, rn) ||
+				(i != 0 && unicode.IsDigit(rn)))
+		simple = simple && isSym
+	}
+	if simple {
+		r = s
+	} else {
+		r = "|" + s + "|"
+	}
+	return
+}
+
+const smtSymChars = "~!@$%^&*_-+=<>.?/"
+
+// smtInvalid returns a name in p that smtSymbol can't write,
+// since quoted symbols can't contain '|' nor '\', or the empty
+// string when there isn't any
+func smtInvalid(p *Predicate) (name string) {
+	if p.Operator == AtomOp {
+		name = String(p)
+	} else if p.Operator == Term || p.Operator == VarOp ||
+		p.Operator == LetOp {
+		name = p.String
+	}
+	if !strings.ContainsAny(name, `|\`) {
+		name = ""
+	}
+	cs := append([]*Predicate{p.A, p.B, p.C}, p.Args...)
+	for i := 0; name == "" && i != len(cs); i++ {
+		if cs[i] != nil {
+			name = smtInvalid(cs[i])
+		}
+	}
+	return
+}
+
+// smtReserved has the reserved words of SMT-LIB, its commands,
+// and the sorts and functions of the Core, Ints, Reals and
+// Strings theories
+var smtReserved = map[string]bool{
+	"!": true, "_": true, "as": true, "BINARY": true, "DECIMAL": true,
+	"exists": true, "forall": true, "HEXADECIMAL": true, "let": true,
+	"match": true, "NUMERAL": true, "par": true, "STRING": true,
+	"assert": true, "check-sat": true, "declare-const": true,
+	"declare-fun": true, "declare-sort": true, "define-fun": true,
+	"define-sort": true, "exit": true, "get-model": true,
+	"get-value": true, "pop": true, "push": true, "set-logic": true,
+	"set-option": true, "set-info": true,
+	"Bool": true, "Int": true, "Real": true, "String": true,
+	"true": true, "false": true, "not": true, "=>": true, "and": true,
+	"or": true, "xor": true, "=": true, "distinct": true, "ite": true,
+	"+": true, "-": true, "*": true, "/": true, "div": true, "mod": true,
+	"abs": true, "<=": true, "<": true, ">=": true, ">": true,
+	"to_real": true, "to_int": true, "is_int": true,
+}
+
+// SMTSymbolErr is returned by WriteSMTLIB when a name can't be
+// written as an SMT-LIB symbol
+type SMTSymbolErr struct {
+	Name string
+}
+
+func (s *SMTSymbolErr) Error() (r string) {
+	r = fmt.Sprintf("'%s' can't be an SMT-LIB symbol", s.Name)
+	return
+}
+
+// SMTStatusErr is returned by ReadSMTModel when the solver
+// didn't answer sat
+type SMTStatusErr struct {
+	Status string
+}
+
+func (s *SMTStatusErr) Error() (r string) {
+	r = fmt.Sprintf("Solver answered '%s', expecting 'sat'", s.Status)
+	return
+}
+
+// ReadSMTModel reads the output of a solver that ran a script
+// produced by WriteSMTLIB, and returns the model as a NameBool
+// that also defines the constants true and false
+func ReadSMTModel(rd io.Reader) (m NameBool, e error) {
+	tks := smtTokens(bufio.NewReader(rd))
+	var st string
+	st, e = tks()
+	if e == nil && st != "sat" {
+		e = &SMTStatusErr{Status: st}
+	}
+	var model *sexp
+	if e == nil {
+		model, e = readSexp(tks)
+	}
+	vals := map[string]bool{TrueStr: true, FalseStr: false}
+	if e == nil {
+		defs := model.list
+		if len(defs) != 0 && defs[0].atom == "model" {
+			// old style (model (define-fun …) …)
+			defs = defs[1:]
+		}
+		for i := 0; e == nil && i != len(defs); i++ {
+			e = defineFun(defs[i], vals)
+		}
+	}
+	if e == nil {
+		m = func(name string) (v, ok bool) {
+			v, ok = vals[name]
+			return
+		}
+	}
+	return
+}
+
+func defineFun(d *sexp, vals map[string]bool) (e error) {
+	// (define-fun name () Bool value), where definitions of other
+	// sorts are skipped whatever their value is
+	l := d.list
+	ok := len(l) == 5 && l[0].atom == "define-fun"
+	if ok && l[3].atom == "Bool" && l[4].atom != "" {
+		vals[l[1].atom] = l[4].atom == TrueStr
+	} else if !ok || l[3].atom == "Bool" {
+		e = &NotRecognizedErr{
+			String:    d.String(),
+			Expecting: []string{"(define-fun name () Bool value)"},
+		}
+	}
+	return
+}
+
+type sexp struct {
+	atom string
+	list []*sexp
+}
+
+func (s *sexp) String() (r string) {
+	if s.list == nil {
+		r = s.atom
+	} else {
+		ss := make([]string, len(s.list))
+		for i, x := range s.list {
+			ss[i] = x.String()
+		}
+		r = "(" + strings.Join(ss, " ") + ")"
+	}
+	return
+}
+
+func readSexp(tks func() (string, error)) (s *sexp, e error) {
+	var t string
+	t, e = tks()
+	if e == nil && t == OPar {
+		s = &sexp{list: []*sexp{}}
+		var x *sexp
+		for e == nil && t != CPar {
+			x, e = readSexp(tks)
+			if e == nil && x.list == nil && x.atom == CPar {
+				t = CPar
+			} else if e == nil {
+				s.list = append(s.list, x)
+			}
+		}
+	} else if e == nil {
+		if t == "" {
+			e = &NotRecognizedErr{String: string(eof),
+				Expecting: []string{OPar}}
+		}
+		s = &sexp{atom: t}
+	}
+	return
+}
+
+// smtTokens splits the solver output in parenthesis, atoms,
+// |quoted| symbols and "string" literals, which keep their
+// quotes, returning the empty string at the end
+func smtTokens(rd *bufio.Reader) (tf func() (string, error)) {
+	tf = func() (t string, e error) {
+		var rn rune
+		rn, _, e = rd.ReadRune()
+		for e == nil && unicode.IsSpace(rn) {
+			rn, _, e = rd.ReadRune()
+		}
+		if e == nil && (rn == '(' || rn == ')') {
+			t = string(rn)
+		} else if e == nil && rn == '|' {
+			var s string
+			s, e = rd.ReadString('|')
+			t = strings.TrimSuffix(s, "|")
+		} else if e == nil && rn == '"' {
+			// a quote inside the literal is written twice
+			var sb strings.Builder
+			sb.WriteRune(rn)
+			closed := false
+			for e == nil && !closed {
+				rn, _, e = rd.ReadRune()
+				if e == nil {
+					sb.WriteRune(rn)
+				}
+				if e == nil && rn == '"' {
+					rn, _, e = rd.ReadRune()
+					closed = e != nil || rn != '"'
+					if e == nil && closed {
+						e = rd.UnreadRune()
+					} else if e == nil {
+						sb.WriteRune(rn)
+					}
+				}
+			}
+			t = sb.String()
+		} else if e == nil {
+			var sb strings.Builder
+			for e == nil && !unicode.IsSpace(rn) && rn != '(' &&
+				rn != ')' {
+				sb.WriteRune(rn)
+				rn, _, e = rd.ReadRune()
+			}
+			if e == nil {
+				e = rd.UnreadRune()
+			}
+			t = sb.String()
+		}
+		if e == io.EOF {
+			e = nil
+		}
+		return
+	}
+	return
+}
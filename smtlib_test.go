@@ -0,0 +1,162 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"errors"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteSMTLIB(t *testing.T) {
+	ps := []struct {
+		pred   string
+		assert string
+	}{
+		{"A", "A"},
+		{"¬A ∧ true", "(and (not A) true)"},
+		{"A ⇐ B", "(=> B A)"},
+		{"A ≡ B ≢ C", "(= A (xor B C))"},
+		{"x1 ∨ (y ⇒ false)", "(or x1 (=> y false))"},
+		{"A ⊕ B ↓ C", "(xor A (not (or B C)))"},
+		{"if A then B else ¬C", "(ite A B (not C))"},
+		{"let x := A in x ↑ B", "(let ((x A)) (not (and x B)))"},
+		{"exactly(1; A, ¬B)", "(= (+ (ite A 1 0) (ite (not B) 1 0)) 1)"},
+		{"atmost(0; A)", "(<= (ite A 1 0) 0)"},
+		{"∀ x ∈ {a, b} : p(x)", "(and |p(a)| |p(b)|)"},
+		{`age ≥ 18 ∧ tag ∈ {"a", "b"}`,
+			`(and (>= age 18.0) (or (= tag "a") (= tag "b")))`},
+		{`x ≠ -0.5`, "(distinct x (- 0.5))"},
+	}
+	inf := func(i int) {
+		p, e := Parse(strings.NewReader(ps[i].pred))
+		require.NoError(t, e)
+		var sb strings.Builder
+		e = WriteSMTLIB(&sb, p)
+		require.NoError(t, e)
+		require.Contains(t, sb.String(), "(assert "+ps[i].assert+")\n")
+		for _, v := range Vars(p) {
+			require.Contains(t, sb.String(),
+				"(declare-fun "+v+" () Bool)\n")
+		}
+		logic := "(set-logic QF_UF)\n"
+		if hasOperator(p, isComparison) {
+			logic = "(set-logic ALL)\n"
+		} else if hasOperator(p, isCard) {
+			logic = "(set-logic QF_LIA)\n"
+		}
+		require.True(t, strings.HasPrefix(sb.String(), logic))
+		require.True(t, strings.HasSuffix(sb.String(),
+			"(check-sat)\n(get-model)\n"))
+	}
+	alg.Forall(inf, len(ps))
+}
+
+func TestSMTSorts(t *testing.T) {
+	p, e := Parse(strings.NewReader(
+		`a < 1 ∧ b = "é\"" ∧ a = c ∧ d ∈ {"x"}`))
+	require.NoError(t, e)
+	var sb strings.Builder
+	e = WriteSMTLIB(&sb, p)
+	require.NoError(t, e)
+	require.Equal(t, "(set-logic ALL)\n"+
+		"(declare-fun a () Real)\n"+
+		"(declare-fun b () String)\n"+
+		"(declare-fun c () Real)\n"+
+		"(declare-fun d () String)\n"+
+		`(assert (and (< a 1.0) (and (= b "\u{e9}""") `+
+		`(and (= a c) (= d "x")))))`+"\n(check-sat)\n(get-model)\n",
+		sb.String())
+}
+
+func TestSMTSymbol(t *testing.T) {
+	ps := [][]string{
+		{"A", "A"},
+		{"x_1", "x_1"},
+		{"1x", "|1x|"},
+		{"café", "|café|"},
+		{"and", "|and|"},
+		{"ite", "|ite|"},
+		{"let", "|let|"},
+		{"Bool", "|Bool|"},
+		{"str.len", "|str.len|"},
+		{"@x", "|@x|"},
+		{"android", "android"},
+	}
+	inf := func(i int) {
+		require.Equal(t, ps[i][1], smtSymbol(ps[i][0]))
+	}
+	alg.Forall(inf, len(ps))
+
+	ns := []string{"`a|b`", "`a\\\\b` ∧ A", "p(`x|y`)", "`n|m` < 1",
+		"let `a|b` := A in `a|b`"}
+	inf = func(i int) {
+		p, e := Parse(strings.NewReader(ns[i]))
+		require.NoError(t, e, "At %d", i)
+		var sy *SMTSymbolErr
+		require.True(t, errors.As(WriteSMTLIB(io.Discard, p), &sy),
+			"At %d", i)
+	}
+	alg.Forall(inf, len(ns))
+}
+
+func TestReadSMTModel(t *testing.T) {
+	out := "sat\n(\n  (define-fun B () Bool\n    false)\n" +
+		"  (define-fun A () Bool\n    true)\n" +
+		"  (define-fun |has space| () Bool true)\n)\n"
+	m, e := ReadSMTModel(strings.NewReader(out))
+	require.NoError(t, e)
+	p, e := Parse(strings.NewReader("A ∧ ¬B"))
+	require.NoError(t, e)
+	require.Equal(t, TrueStr, String(Reduce(p, m)))
+	v, ok := m("has space")
+	require.True(t, v && ok)
+	_, ok = m("C")
+	require.False(t, ok)
+
+	old := "sat (model (define-fun A () Bool false))"
+	m, e = ReadSMTModel(strings.NewReader(old))
+	require.NoError(t, e)
+	v, ok = m("A")
+	require.True(t, !v && ok)
+
+	other := "sat\n(\n  (define-fun r () Real\n    (- 1.0))\n" +
+		"  (define-fun s () String \"a b\")\n" +
+		"  (define-fun u () String \"x\"\")(\")\n" +
+		"  (define-fun A () Bool true)\n)\n"
+	m, e = ReadSMTModel(strings.NewReader(other))
+	require.NoError(t, e)
+	v, ok = m("A")
+	require.True(t, v && ok)
+	_, ok = m("s")
+	require.False(t, ok)
+
+	_, e = ReadSMTModel(strings.NewReader("unsat\n"))
+	var st *SMTStatusErr
+	require.True(t, errors.As(e, &st))
+	require.Equal(t, "unsat", st.Status)
+
+	_, e = ReadSMTModel(strings.NewReader("sat ((define-fun A"))
+	require.Error(t, e)
+}
@@ -0,0 +1,158 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+// Solver decides the satisfiability of a set of predicates that
+// changes incrementally, keeping what it learns from one check
+// to the next. Predicates added after Push are removed by the
+// matching Pop
+type Solver struct {
+	sat *cdcl
+	enc *tseitin
+	// fed is the amount of clauses of enc already given to sat
+	fed int
+	// scopes has the variables that enable the predicates added
+	// after each Push
+	scopes []int
+	// assumed has the literals of the assumptions encoded by
+	// previous checks, by their key, so repeating one
+	// doesn't encode it again
+	assumed map[string]int
+	model   map[string]bool
+}
+
+// NewSolver returns a Solver without predicates, which is
+// satisfiable
+func NewSolver() (s *Solver) {
+	s = &Solver{
+		sat:     newCDCL(),
+		enc:     &tseitin{cnf: new(CNF), vars: make(map[string]int)},
+		assumed: make(map[string]int),
+	}
+	return
+}
+
+// Add adds p to the predicates that must hold, which must be
+// propositional, as described in UnsupportedErr
+func (s *Solver) Add(p *Predicate) (e error) {
+	x, e := propositional(p)
+	if e == nil {
+		s.declare(x)
+		for _, q := range conjuncts(x) {
+			cl := []int{s.enc.lit(q)}
+			if len(s.scopes) != 0 {
+				cl = append(cl, -s.scopes[len(s.scopes)-1])
+			}
+			s.enc.add(cl...)
+		}
+		s.feed()
+	}
+	return
+}
+
+// Push opens a scope, where the predicates added until the
+// matching Pop are kept
+func (s *Solver) Push() {
+	v := s.enc.fresh()
+	if len(s.scopes) != 0 {
+		// a scope holds only while the enclosing ones hold
+		s.enc.add(-v, s.scopes[len(s.scopes)-1])
+	}
+	s.scopes = append(s.scopes, v)
+	s.feed()
+}
+
+// Pop removes the predicates added since the last Push, and
+// does nothing when there isn't a scope open
+func (s *Solver) Pop() {
+	if len(s.scopes) != 0 {
+		// the clauses of the scope, and the ones learned from
+		// them, are satisfied from now on
+		s.enc.add(-s.scopes[len(s.scopes)-1])
+		s.scopes = s.scopes[:len(s.scopes)-1]
+		s.feed()
+	}
+}
+
+// Check returns whether the predicates added are satisfiable
+// together with the assumptions, which hold only during this
+// check. When they are, Model returns a model. The encoding of
+// an assumption is kept for the following checks, whose clauses
+// only define its literal and don't constrain the predicates
+func (s *Solver) Check(assumptions ...*Predicate) (ok bool, e error) {
+	as := append([]int{}, s.scopes...)
+	for i := 0; e == nil && i != len(assumptions); i++ {
+		var x *Predicate
+		x, e = propositional(assumptions[i])
+		var l int
+		if e == nil {
+			k := key(x)
+			l = s.assumed[k]
+			if l == 0 {
+				s.declare(x)
+				l = s.enc.lit(x)
+				s.assumed[k] = l
+			}
+		}
+		if e == nil {
+			as = append(as, l)
+		}
+	}
+	s.model = nil
+	if e == nil {
+		s.feed()
+		ok, _ = s.sat.solve(as)
+	}
+	if ok {
+		s.model = make(map[string]bool)
+		for k, v := range s.enc.vars {
+			s.model[k] = s.sat.value[v] == 1
+		}
+	}
+	return
+}
+
+// Model returns the values of the variables in the predicates
+// and assumptions given to the solver, found by the last Check,
+// or nil when it was unsatisfiable
+func (s *Solver) Model() (m map[string]bool) {
+	m = s.model
+	return
+}
+
+// declare numbers the variables of x that haven't a number yet
+func (s *Solver) declare(x *Predicate) {
+	for _, v := range Vars(x) {
+		if _, ok := s.enc.vars[v]; !ok {
+			s.enc.vars[v] = s.enc.fresh()
+		}
+	}
+}
+
+// feed gives to the SAT solver the clauses encoded since the
+// last call
+func (s *Solver) feed() {
+	s.sat.grow(s.enc.cnf.NumVars)
+	for _, cl := range s.enc.cnf.Clauses[s.fed:] {
+		s.sat.addClause(cl)
+	}
+	s.fed = len(s.enc.cnf.Clauses)
+}
@@ -0,0 +1,141 @@
+// Copyright © 2019 Luis Ángel Méndez Gort
+
+// This file is part of Predicate.
+
+// Predicate is free software: you can redistribute it and/or
+// modify it under the terms of the GNU Lesser General
+// Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// Predicate is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the
+// implied warranty of MERCHANTABILITY or FITNESS FOR A
+// PARTICULAR PURPOSE. See the GNU Lesser General Public
+// License for more details.
+
+// You should have received a copy of the GNU Lesser General
+// Public License along with Predicate.  If not, see
+// <https://www.gnu.org/licenses/>.
+
+package predicate
+
+import (
+	"fmt"
+	alg "github.com/lamg/algorithms"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSolver(t *testing.T) {
+	parse := func(s string) (p *Predicate) {
+		p, e := Parse(strings.NewReader(s))
+		require.NoError(t, e)
+		return
+	}
+	s := NewSolver()
+	ok, e := s.Check()
+	require.NoError(t, e)
+	require.True(t, ok)
+	require.NoError(t, s.Add(parse("A ∨ B")))
+	require.NoError(t, s.Add(parse("exactly(1; A, B, C)")))
+	s.Push()
+	require.NoError(t, s.Add(parse("¬A")))
+	ok, e = s.Check()
+	require.NoError(t, e)
+	require.True(t, ok)
+	require.Equal(t, map[string]bool{"A": false, "B": true, "C": false},
+		s.Model())
+	s.Push()
+	require.NoError(t, s.Add(parse("¬B")))
+	ok, e = s.Check()
+	require.NoError(t, e)
+	require.False(t, ok)
+	require.Nil(t, s.Model())
+	s.Pop()
+	ok, e = s.Check(parse("B ⇒ D"), parse("¬D"))
+	require.NoError(t, e)
+	require.False(t, ok)
+	s.Pop()
+	s.Pop()
+	ok, e = s.Check(parse("¬B"))
+	require.NoError(t, e)
+	require.True(t, ok)
+	require.True(t, s.Model()["A"])
+	ok, e = s.Check(parse("A ∧ ¬A"))
+	require.NoError(t, e)
+	require.False(t, ok)
+	// repeating assumptions doesn't encode them again
+	n := len(s.enc.cnf.Clauses)
+	for i := 0; i != 3; i++ {
+		ok, e = s.Check(parse("A ∧ ¬A"), parse("B ⇒ D"))
+		require.NoError(t, e)
+		require.False(t, ok)
+	}
+	require.Equal(t, n, len(s.enc.cnf.Clauses))
+	ok, e = s.Check(parse("B ⇒ D"))
+	require.NoError(t, e)
+	require.True(t, ok)
+	require.Error(t, s.Add(parse("a < 1")))
+	_, e = s.Check(parse("a < 1"))
+	require.Error(t, e)
+	// assumptions that only differ in how they associate
+	s = NewSolver()
+	na, nc := parse("¬A"), parse("¬C")
+	ok, e = s.Check(parse("(A ⇒ B) ⇒ C"), na, nc)
+	require.NoError(t, e)
+	require.False(t, ok)
+	ok, e = s.Check(parse("A ⇒ (B ⇒ C)"), na, nc)
+	require.NoError(t, e)
+	require.True(t, ok)
+}
+
+func TestSolverIncremental(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	clause := func() (p *Predicate) {
+		var ls []string
+		for i := 0; i != 3; i++ {
+			l := fmt.Sprintf("x%d", rd.Intn(6))
+			if rd.Intn(2) == 0 {
+				l = "¬" + l
+			}
+			ls = append(ls, l)
+		}
+		p, e := Parse(strings.NewReader(strings.Join(ls, " ∨ ")))
+		require.NoError(t, e)
+		return
+	}
+	s := NewSolver()
+	// scopes has the predicates added in each scope
+	scopes := [][]*Predicate{nil}
+	inf := func(i int) {
+		op := rd.Intn(5)
+		if op == 0 {
+			s.Push()
+			scopes = append(scopes, nil)
+		} else if op == 1 && len(scopes) != 1 {
+			s.Pop()
+			scopes = scopes[:len(scopes)-1]
+		} else {
+			p := clause()
+			require.NoError(t, s.Add(p))
+			scopes[len(scopes)-1] = append(scopes[len(scopes)-1], p)
+		}
+		var ps []*Predicate
+		for _, sc := range scopes {
+			ps = append(ps, sc...)
+		}
+		ok, e := s.Check()
+		require.NoError(t, e)
+		core, e := UnsatCore(ps)
+		require.NoError(t, e)
+		require.Equal(t, len(core) == 0, ok, "At %d", i)
+		for j := 0; ok && j != len(ps); j++ {
+			m := assignInterp(s.Model())
+			require.Equal(t, TrueStr, Reduce(ps[j], m).String, "At %d", i)
+		}
+	}
+	alg.Forall(inf, 300)
+}